@@ -0,0 +1,214 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// AliasSource provides the user-defined aliases, mapping the alias names to
+// the command lines they expand to (e.g. "co" to "checkout -b").
+type AliasSource interface {
+	Aliases() (map[string]string, error)
+}
+
+// AliasMap is an AliasSource backed by a map.
+type AliasMap map[string]string
+
+// Aliases implements AliasSource.
+func (m AliasMap) Aliases() (map[string]string, error) {
+	return m, nil
+}
+
+// AliasFile is an AliasSource that reads the aliases from a config file with
+// "name = expansion" lines. Like with git, the names may be prefixed with
+// "alias." or be placed in an "[alias]" section:
+//
+//	alias.co = checkout -b
+//
+//	[alias]
+//	st = status --short
+//
+// Lines starting with # or ; are comments. A file that does not exist has no aliases.
+type AliasFile string
+
+// Aliases implements AliasSource.
+func (f AliasFile) Aliases() (map[string]string, error) {
+	file, err := os.Open(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	aliases := make(map[string]string)
+	var section string
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected name = expansion", f, n)
+		}
+		name := strings.TrimSpace(line[:i])
+		expansion := strings.TrimSpace(line[i+1:])
+		switch {
+		case strings.HasPrefix(name, "alias."):
+			name = strings.TrimPrefix(name, "alias.")
+		case section != "" && section != "alias":
+			continue
+		}
+		aliases[name] = expansion
+	}
+	return aliases, scanner.Err()
+}
+
+// EnableAliases adds the aliases of the source to the root command.
+//
+// Every alias becomes a subcommand of the root command, so it appears in the
+// help and the shell completion. When executed, it expands its command line,
+// finds the command it refers to and redirects to it (see Redirect) with the
+// expanded arguments followed by the arguments of the alias, which may hold
+// the help flag. The PersistentPreRun hooks run once, for the command the
+// alias refers to. Aliases that have the same name as an existing command
+// are ignored.
+func EnableAliases(root *cobra.Command, source AliasSource) error {
+	aliases, err := source.Aliases()
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" || hasCommand(root, name) {
+			continue
+		}
+		words, err := splitCommandLine(aliases[name])
+		if err != nil {
+			return fmt.Errorf("alias %q: %v", name, err)
+		}
+		addAlias(root, name, aliases[name], words)
+	}
+	return nil
+}
+
+func hasCommand(root *cobra.Command, name string) bool {
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func addAlias(root *cobra.Command, name string, expansion string, words []string) {
+	aliasCmd := &cobra.Command{
+		Use:   name,
+		Short: fmt.Sprintf("Alias for %q", expansion),
+		// The flags are parsed by the command the alias refers to
+		DisableFlagParsing: true,
+	}
+	// resolve finds the command the alias refers to and its arguments
+	resolve := func(args []string) (*cobra.Command, []string, error) {
+		cmd, rest, err := root.Find(words)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cmd, append(append([]string{}, rest...), args...), nil
+	}
+	aliasCmd.ValidArgsFunction = func(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		cmd, args, err := resolve(args)
+		if err != nil || cmd.ValidArgsFunction == nil {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		if err := cmd.ParseFlags(args); err != nil {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return cmd.ValidArgsFunction(cmd, cmd.Flags().Args(), toComplete)
+	}
+	// Redirect before the PersistentPreRun hooks of the parents run, they
+	// run for the command the alias refers to. The help flag is handled by
+	// the redirect.
+	aliasCmd.PersistentPreRunE = func(c *cobra.Command, args []string) error {
+		cmd, args, err := resolve(args)
+		if err != nil {
+			return err
+		}
+		e := currentExecution(c)
+		e.redirected = true
+		return redirect(e, &RedirectError{Command: cmd, Args: args})
+	}
+	// Make the alias command runnable
+	aliasCmd.RunE = func(_ *cobra.Command, _ []string) error { return nil }
+	root.AddCommand(aliasCmd)
+}
+
+// splitCommandLine splits the command line into words, supporting single
+// and double quotes and backslash escapes
+func splitCommandLine(s string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("unterminated escape")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,172 @@
+package cobrahooks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestEnableAliases(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root", Run: emptyRun}
+	checkoutCmd := &cobra.Command{Use: "checkout", Short: "Switch branches"}
+	rootCmd.AddCommand(checkoutCmd)
+
+	var (
+		gotArgs []string
+		branch  bool
+	)
+	checkoutCmd.Flags().BoolVarP(&branch, "branch", "b", false, "")
+	OnRun(checkoutCmd, func(cmd *cobra.Command, args []string) error {
+		gotArgs = args
+		return nil
+	})
+	checkoutCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"main", "develop"}, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	err := EnableAliases(rootCmd, AliasMap{
+		"co":       "checkout -b",
+		"checkout": "status",
+		"quoted":   `checkout "feature x"`,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(rootCmd, "co", "topic"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !branch || !reflect.DeepEqual(gotArgs, []string{"topic"}) {
+		t.Errorf("Expected checkout -b topic, got branch=%v args=%q", branch, gotArgs)
+	}
+
+	branch = false
+	if _, err := executeCommand(rootCmd, "quoted"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if branch || !reflect.DeepEqual(gotArgs, []string{"feature x"}) {
+		t.Errorf("Expected checkout \"feature x\", got branch=%v args=%q", branch, gotArgs)
+	}
+
+	output, err := executeCommand(rootCmd, "--help")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, `co          Alias for "checkout -b"`)
+	// Aliases do not replace existing commands
+	checkStringContains(t, output, "checkout    Switch branches")
+	checkStringOmits(t, output, `Alias for "status"`)
+
+	output, err = executeCommand(rootCmd, "__complete", "c")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "co\tAlias for \"checkout -b\"")
+
+	output, err = executeCommand(rootCmd, "__complete", "co", "")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "main\ndevelop\n:4")
+}
+
+func TestAliasHelpAndPersistentHooks(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root", Run: emptyRun}
+	checkoutCmd := &cobra.Command{Use: "checkout", Short: "Switch branches"}
+	rootCmd.AddCommand(checkoutCmd)
+
+	var persPre, persPost, run int
+	OnPersistentPreRun(rootCmd, func(_ *cobra.Command, _ []string) error {
+		persPre++
+		return nil
+	})
+	OnPersistentPostRun(rootCmd, func(_ *cobra.Command, _ []string) error {
+		persPost++
+		return nil
+	})
+	OnRun(checkoutCmd, func(_ *cobra.Command, _ []string) error {
+		run++
+		return nil
+	})
+	if err := EnableAliases(rootCmd, AliasMap{"co": "checkout"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := executeCommand(rootCmd, "co", "main"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if persPre != 1 || run != 1 || persPost != 1 {
+		t.Errorf("Expected the hooks to run once, got persPre=%d run=%d persPost=%d", persPre, run, persPost)
+	}
+
+	for _, flag := range []string{"--help", "-h"} {
+		persPre, run = 0, 0
+		output, err := executeCommand(rootCmd, "co", flag)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		checkStringContains(t, output, "Switch branches")
+		checkStringContains(t, output, "root checkout [flags]")
+		if persPre != 0 || run != 0 {
+			t.Errorf("Expected the help instead of the hooks for %s, got persPre=%d run=%d", flag, persPre, run)
+		}
+		checkoutCmd.Flags().Set("help", "false")
+	}
+}
+
+func TestAliasFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobrahooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config")
+	config := strings.Join([]string{
+		"# aliases",
+		"alias.co = checkout -b",
+		"[core]",
+		"editor = vim",
+		"[alias]",
+		"; short status",
+		"st = status --short",
+		"",
+	}, "\n")
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	aliases, err := AliasFile(path).Aliases()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{"co": "checkout -b", "st": "status --short"}
+	if !reflect.DeepEqual(aliases, expected) {
+		t.Errorf("Expected %v, got %v", expected, aliases)
+	}
+
+	aliases, err = AliasFile(filepath.Join(dir, "missing")).Aliases()
+	if err != nil || len(aliases) != 0 {
+		t.Errorf("Expected no aliases for a missing file, got %v, %v", aliases, err)
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	words, err := splitCommandLine(`log --format='%h %s' "a b"\ c plain\ word`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"log", "--format=%h %s", "a b c", "plain word"}
+	if !reflect.DeepEqual(words, expected) {
+		t.Errorf("Expected %q, got %q", expected, words)
+	}
+
+	if _, err := splitCommandLine(`log "unterminated`); err == nil {
+		t.Errorf("Expected an error for an unterminated quote")
+	}
+}
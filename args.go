@@ -0,0 +1,30 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// OnRewriteArgs registers a PreRun hook on the command that rewrites the arguments.
+func (c *Command) OnRewriteArgs(h func(cmd *cobra.Command, args []string) ([]string, error), options ...func(*HookOptions)) {
+	OnRewriteArgs(c.Command, h, options...)
+}
+
+// OnRewriteArgs registers a PreRun hook on the command that rewrites the
+// arguments. The returned arguments are passed to the subsequent hooks,
+// including the Run, PostRun and PersistentPostRun hooks, which allows for
+// alias expansion, argument files, glob expansion and the like.
+// Use the Persistent option to register it as a PersistentPreRun hook.
+//
+// Note that user-defined (Pre/Post)Run(E) fields still receive the original
+// arguments, and the arguments are validated by cobra before any hook runs.
+func OnRewriteArgs(c *cobra.Command, h func(cmd *cobra.Command, args []string) ([]string, error), options ...func(*HookOptions)) {
+	p, opts := hookOptions(PreRunPhase, options)
+	ch := newCommandHook(c, p, nil, opts)
+	ch.rewrite = h
+	// Register the hook
+	register(ch)
+}
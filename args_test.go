@@ -0,0 +1,88 @@
+package cobrahooks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRewriteArgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobrahooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	argsFile := filepath.Join(dir, "args")
+	if err := ioutil.WriteFile(argsFile, []byte("two three\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	childCmd := &Command{&cobra.Command{Use: "child"}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	var (
+		preArgs     string
+		runArgs     string
+		persPostArg string
+	)
+
+	// Expand @file arguments
+	rootCmd.OnRewriteArgs(func(_ *cobra.Command, args []string) ([]string, error) {
+		var expanded []string
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "@") {
+				b, err := ioutil.ReadFile(arg[1:])
+				if err != nil {
+					return nil, err
+				}
+				expanded = append(expanded, strings.Fields(string(b))...)
+				continue
+			}
+			expanded = append(expanded, arg)
+		}
+		return expanded, nil
+	}, Persistent)
+	childCmd.OnPreRun(func(_ *cobra.Command, args []string) error {
+		preArgs = strings.Join(args, " ")
+		return nil
+	})
+	// Expand aliases
+	childCmd.OnRewriteArgs(func(_ *cobra.Command, args []string) ([]string, error) {
+		for i, arg := range args {
+			if arg == "1" {
+				args[i] = "one"
+			}
+		}
+		return args, nil
+	})
+	childCmd.OnRun(func(_ *cobra.Command, args []string) error {
+		runArgs = strings.Join(args, " ")
+		return nil
+	})
+	rootCmd.OnPersistentPostRun(func(_ *cobra.Command, args []string) error {
+		persPostArg = strings.Join(args, " ")
+		return nil
+	})
+
+	if _, err := executeCommand(rootCmd.Command, "child", "1", "@"+argsFile); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if preArgs != "1 two three" {
+		t.Errorf("Expected preArgs %q, got %q", "1 two three", preArgs)
+	}
+	if runArgs != "one two three" {
+		t.Errorf("Expected runArgs %q, got %q", "one two three", runArgs)
+	}
+	if persPostArg != "one two three" {
+		t.Errorf("Expected persPostArgs %q, got %q", "one two three", persPostArg)
+	}
+
+	if _, err := executeCommand(rootCmd.Command, "child", "@"+filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("Expected error")
+	}
+}
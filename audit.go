@@ -0,0 +1,199 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// SecretAnnotation marks flags and commands whose values are redacted in the audit log.
+const SecretAnnotation = "cobrahooks_secret"
+
+// Redacted replaces the secret values in the audit log.
+const Redacted = "[REDACTED]"
+
+// MarkFlagSecret marks the flag of the flag set as secret, its value is
+// redacted in the audit log.
+func MarkFlagSecret(flags *pflag.FlagSet, name string) error {
+	return flags.SetAnnotation(name, SecretAnnotation, []string{"true"})
+}
+
+// MarkArgsSecret marks the arguments of the command as secret, they are
+// redacted in the audit log.
+func MarkArgsSecret(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string)
+	}
+	cmd.Annotations[SecretAnnotation] = "true"
+}
+
+// AuditRecord is the record written to the audit log for every invocation.
+type AuditRecord struct {
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	Command string    `json:"command"`
+	Args    []string  `json:"args"`
+	// Flags holds the values of the flags set on the command line
+	Flags      map[string]string `json:"flags,omitempty"`
+	ExitStatus int               `json:"exitStatus"`
+	Error      string            `json:"error,omitempty"`
+	Duration   time.Duration     `json:"duration"`
+}
+
+// AuditOptions configures AuditLog.
+type AuditOptions struct {
+	// User returns the user to record, the user running the process by default
+	User func() string
+}
+
+// AuditLog writes an AuditRecord for every invocation of the root command to
+// w, as one JSON object per line: the user, the command path, the arguments,
+// the flags set on the command line, the exit status and the duration.
+// The values of the flags marked with MarkFlagSecret and the arguments of the
+// commands marked with MarkArgsSecret are redacted, also from the error.
+//
+// The records are written after the Finally hooks, whatever the hooks
+// return, so the invocations have to be executed through Execute, ExecuteC
+// or ExecuteAndExit, the other executions fail instead of going unaudited.
+// Failing to write a record does not fail the invocation, the error is
+// printed to stderr instead.
+func AuditLog(root *cobra.Command, w io.Writer, opts AuditOptions) {
+	if opts.User == nil {
+		opts.User = currentUser
+	}
+	OnPersistentPreRun(root, func(cmd *cobra.Command, _ []string) error {
+		if !executing[cmd.Root()] {
+			return errors.New("cobrahooks: the audit log requires the execution through cobrahooks.Execute")
+		}
+		return nil
+	})
+	executeWrappers[root] = append(executeWrappers[root], func() func(*Invocation) {
+		return func(inv *Invocation) {
+			if inv == nil {
+				return
+			}
+			rec := AuditRecord{
+				Time:     inv.Start,
+				User:     opts.User(),
+				Command:  inv.Command.CommandPath(),
+				Args:     auditArgs(inv.Command, inv.Args),
+				Flags:    auditFlags(inv.Command, inv.Changed),
+				Duration: time.Since(inv.Start),
+			}
+			if inv.Err != nil {
+				rec.ExitStatus = ExitCode(inv.Err)
+				rec.Error = redactError(inv, inv.Err.Error())
+			}
+			if err := json.NewEncoder(w).Encode(rec); err != nil {
+				fmt.Fprintf(root.ErrOrStderr(), "cobrahooks: audit log: %v\n", err)
+			}
+		}
+	})
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+func isSecret(annotations map[string][]string) bool {
+	return len(annotations[SecretAnnotation]) > 0 && annotations[SecretAnnotation][0] == "true"
+}
+
+// auditArgs returns the arguments to record
+func auditArgs(cmd *cobra.Command, args []string) []string {
+	recorded := make([]string, len(args))
+	secret := cmd.Annotations[SecretAnnotation] == "true"
+	for i, arg := range args {
+		if secret {
+			arg = Redacted
+		}
+		recorded[i] = arg
+	}
+	return recorded
+}
+
+// auditFlags returns the values of the changed flags to record
+func auditFlags(cmd *cobra.Command, changed map[string]bool) map[string]string {
+	if len(changed) == 0 {
+		return nil
+	}
+	flags := make(map[string]string, len(changed))
+	for name := range changed {
+		f := cmd.Flags().Lookup(name)
+		if f == nil {
+			continue
+		}
+		if isSecret(f.Annotations) {
+			flags[name] = Redacted
+		} else {
+			flags[name] = f.Value.String()
+		}
+	}
+	return flags
+}
+
+// redactError redacts the values of the secret flags and arguments of the
+// invocation from the error message, e.g. pflag quotes invalid values
+func redactError(inv *Invocation, msg string) string {
+	var secrets []string
+	flags := inv.Command.Flags()
+	flags.VisitAll(func(f *pflag.Flag) {
+		if isSecret(f.Annotations) && f.Changed {
+			secrets = append(secrets, f.Value.String())
+		}
+	})
+	// The values that failed to parse are only in the command line
+	for i := 0; i < len(inv.Argv); i++ {
+		arg := inv.Argv[i]
+		if arg == "--" {
+			break
+		}
+		var f *pflag.Flag
+		value, hasValue := "", false
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			name := arg[2:]
+			if j := strings.Index(name, "="); j >= 0 {
+				name, value, hasValue = name[:j], name[j+1:], true
+			}
+			f = flags.Lookup(name)
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			f = flags.ShorthandLookup(arg[1:2])
+			if len(arg) > 2 {
+				value, hasValue = strings.TrimPrefix(arg[2:], "="), true
+			}
+		}
+		if f == nil || !isSecret(f.Annotations) {
+			continue
+		}
+		if !hasValue && f.NoOptDefVal == "" && i+1 < len(inv.Argv) {
+			i++
+			value = inv.Argv[i]
+		}
+		secrets = append(secrets, value)
+	}
+	if inv.Command.Annotations[SecretAnnotation] == "true" {
+		secrets = append(secrets, inv.Args...)
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			msg = strings.Replace(msg, secret, Redacted, -1)
+		}
+	}
+	return msg
+}
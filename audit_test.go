@@ -0,0 +1,128 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestAuditLog(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "admin"}
+	loginCmd := &cobra.Command{Use: "login", Args: cobra.ExactArgs(1), Run: emptyRun}
+	setCmd := &cobra.Command{Use: "set", Run: emptyRun}
+	rootCmd.AddCommand(loginCmd, setCmd)
+	rootCmd.PersistentFlags().String("region", "", "")
+	loginCmd.Flags().String("password", "", "")
+	if err := MarkFlagSecret(loginCmd.Flags(), "password"); err != nil {
+		t.Fatal(err)
+	}
+	MarkArgsSecret(setCmd)
+	AddSkipHooksFlag(rootCmd)
+
+	log := new(bytes.Buffer)
+	AuditLog(rootCmd, log, AuditOptions{User: func() string { return "alice" }})
+
+	var records []AuditRecord
+	run := func(args ...string) {
+		log.Reset()
+		rootCmd.SetOut(new(bytes.Buffer))
+		SetArgs(rootCmd, args)
+		Execute(rootCmd)
+		var rec AuditRecord
+		if err := json.Unmarshal(log.Bytes(), &rec); err != nil {
+			t.Fatalf("Unexpected audit log %q: %v", log, err)
+		}
+		records = append(records, rec)
+	}
+	run("login", "--region=eu", "--password=hunter2", "bob")
+	run("set", "token", "s3cr3t")
+	run("login", "--skip-hooks=audit")
+
+	rec := records[0]
+	if rec.User != "alice" || rec.Command != "admin login" || rec.ExitStatus != 0 || rec.Time.IsZero() {
+		t.Errorf("Unexpected record: %+v", rec)
+	}
+	if !reflect.DeepEqual(rec.Args, []string{"bob"}) {
+		t.Errorf("Expected the args to be recorded, got %q", rec.Args)
+	}
+	if !reflect.DeepEqual(rec.Flags, map[string]string{"region": "eu", "password": Redacted}) {
+		t.Errorf("Expected the password to be redacted, got %v", rec.Flags)
+	}
+	if !reflect.DeepEqual(records[1].Args, []string{Redacted, Redacted}) {
+		t.Errorf("Expected the args to be redacted, got %q", records[1].Args)
+	}
+	if records[2].ExitStatus != 1 || records[2].Error != "accepts 1 arg(s), received 0" {
+		t.Errorf("Expected the failure to be recorded, got %+v", records[2])
+	}
+}
+
+func TestAuditLogRedactsErrors(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "admin"}
+	unlockCmd := &cobra.Command{Use: "unlock", Run: emptyRun}
+	rootCmd.AddCommand(unlockCmd)
+	unlockCmd.Flags().IntP("pin", "p", 0, "")
+	if err := MarkFlagSecret(unlockCmd.Flags(), "pin"); err != nil {
+		t.Fatal(err)
+	}
+
+	log := new(bytes.Buffer)
+	AuditLog(rootCmd, log, AuditOptions{User: func() string { return "alice" }})
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(out)
+
+	for _, args := range [][]string{
+		{"unlock", "--pin=12ab"},
+		{"unlock", "--pin", "12ab"},
+		{"unlock", "-p12ab"},
+	} {
+		log.Reset()
+		SetArgs(rootCmd, args)
+		if err := Execute(rootCmd); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal(log.Bytes(), &rec); err != nil {
+			t.Fatalf("Unexpected audit log %q: %v", log, err)
+		}
+		if rec.ExitStatus != 1 || rec.Error == "" || strings.Contains(rec.Error, "12ab") {
+			t.Errorf("Expected the pin to be redacted from the error for %q, got %q", args, rec.Error)
+		}
+		checkStringContains(t, rec.Error, `invalid argument "`+Redacted+`" for "-p, --pin" flag`)
+	}
+
+	// Executions that bypass the audit log fail
+	log.Reset()
+	rootCmd.SetArgs([]string{"unlock"})
+	if err := rootCmd.Execute(); err == nil || log.Len() != 0 {
+		t.Errorf("Expected the execution to fail without an audit record, got %v, %q", err, log)
+	}
+}
+
+func TestAuditLogFinallyHooks(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "admin", Run: emptyRun}
+	rootCmd.SetOut(new(bytes.Buffer))
+	var finallyErr error
+	OnFinally(rootCmd, func(_ *cobra.Command, _ []string) error { return finallyErr })
+	log := new(bytes.Buffer)
+	AuditLog(rootCmd, log, AuditOptions{User: func() string { return "alice" }})
+
+	for _, err := range []error{errors.New("flush failed"), ErrStopHooks} {
+		log.Reset()
+		finallyErr = err
+		SetArgs(rootCmd, nil)
+		execErr := Execute(rootCmd)
+		var rec AuditRecord
+		if err := json.Unmarshal(log.Bytes(), &rec); err != nil {
+			t.Fatalf("Expected a record when a Finally hook returns %v, got %q", finallyErr, log)
+		}
+		if (execErr == nil) != (rec.ExitStatus == 0) {
+			t.Errorf("Expected the record to have the status of the execution %v, got %+v", execErr, rec)
+		}
+	}
+}
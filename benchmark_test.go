@@ -0,0 +1,166 @@
+package cobrahooks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	benchDepth  = 10
+	benchFanOut = 50
+)
+
+var benchHookCounts = []int{1, 5, 20}
+
+// deepTree builds a tree of the given depth where every level has fanOut
+// commands, and registers n hooks for every phase on each command.
+// It returns the root and the deepest command, and the command path to it.
+// The hooks are registered globally, isolate them with Isolate.
+func deepTree(depth, fanOut, n int) (root, leaf *cobra.Command, path []string) {
+	hook := func(_ *cobra.Command, _ []string) error { return nil }
+	addHooks := func(c *cobra.Command) {
+		for i := 0; i < n; i++ {
+			OnPersistentPreRun(c, hook, RunOnHelp, RunOnVersion)
+			OnPreRun(c, hook, RunOnHelp, RunOnVersion)
+			OnRun(c, hook)
+			OnPostRun(c, hook)
+			OnPersistentPostRun(c, hook)
+			OnHelp(c, hook, Persistent)
+			OnVersion(c, hook, Persistent)
+		}
+	}
+	root = &cobra.Command{Use: "root", Version: "1.0.0"}
+	root.SetOut(ioutil.Discard)
+	root.SetErr(ioutil.Discard)
+	addHooks(root)
+	parent := root
+	for d := 1; d < depth; d++ {
+		var next *cobra.Command
+		for i := 0; i < fanOut; i++ {
+			c := &cobra.Command{Use: fmt.Sprintf("cmd%d-%d", d, i)}
+			parent.AddCommand(c)
+			addHooks(c)
+			if i == fanOut/2 {
+				next = c
+			}
+		}
+		path = append(path, next.Name())
+		parent = next
+	}
+	return root, parent, path
+}
+
+func BenchmarkPhase(b *testing.B) {
+	for p := PersistentPreRunPhase; p <= PersistentPostRunPhase; p++ {
+		for _, n := range benchHookCounts {
+			p := p
+			b.Run(fmt.Sprintf("%s/hooks=%d", p, n), func(b *testing.B) {
+				defer Isolate()()
+				_, leaf, _ := deepTree(benchDepth, benchFanOut, n)
+				args := []string{}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := dispatch(leaf, args, p); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkHelp(b *testing.B) {
+	for _, n := range benchHookCounts {
+		b.Run(fmt.Sprintf("hooks=%d", n), func(b *testing.B) {
+			defer Isolate()()
+			_, leaf, _ := deepTree(benchDepth, benchFanOut, n)
+			// The help func of the root command set by initHelpHooks
+			help := leaf.HelpFunc()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				help(leaf, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkHelpHooks(b *testing.B) {
+	for _, n := range benchHookCounts {
+		b.Run(fmt.Sprintf("hooks=%d", n), func(b *testing.B) {
+			defer Isolate()()
+			_, leaf, _ := deepTree(benchDepth, benchFanOut, n)
+			args := []string{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := runHelpHooks(leaf, args); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVersionHooks(b *testing.B) {
+	for _, n := range benchHookCounts {
+		b.Run(fmt.Sprintf("hooks=%d", n), func(b *testing.B) {
+			defer Isolate()()
+			_, leaf, _ := deepTree(benchDepth, benchFanOut, n)
+			args := []string{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := runVersionHooks(leaf, args); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkExecute(b *testing.B) {
+	for _, n := range benchHookCounts {
+		b.Run(fmt.Sprintf("hooks=%d", n), func(b *testing.B) {
+			defer Isolate()()
+			root, _, path := deepTree(benchDepth, benchFanOut, n)
+			root.SetArgs(path)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := root.Execute(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDispatchAllocs(t *testing.T) {
+	defer Isolate()()
+	_, leaf, _ := deepTree(benchDepth, benchFanOut, 5)
+	args := []string{}
+	for p := PersistentPreRunPhase; p <= PersistentPostRunPhase; p++ {
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := dispatch(leaf, args, p); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs > 0 {
+			t.Errorf("Expected dispatching the %s hooks not to allocate, got %v allocs", p, allocs)
+		}
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := runHelpHooks(leaf, args); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// The help run starts a new execution
+	if allocs > 2 {
+		t.Errorf("Expected running the help hooks to allocate at most 2 times, got %v allocs", allocs)
+	}
+}
@@ -5,9 +5,23 @@
 package cobrahooks
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	cobra.OnInitialize(resetExecution, initExplain, setInitialized)
+	cobra.AddTemplateFunc("cobrahooksRunVersionHooks", versionTemplateHooks)
+}
+
+// versionTemplateHooks is the template function that runs the version hooks
+func versionTemplateHooks(cmd *cobra.Command) (string, error) {
+	return "", runVersionHooks(cmd, cmd.Flags().Args())
+}
+
 type Command struct {
 	*cobra.Command
 }
@@ -17,222 +31,461 @@ func NewCommand(c *cobra.Command) *Command {
 }
 
 type commandHook struct {
-	cmd        *cobra.Command
-	hook       func(cmd *cobra.Command, args []string) error
-	runOnHelp  bool
-	persistent bool
-}
-
-var (
-	preRunHooks            []*commandHook
-	persistentPreRunHooks  []*commandHook
-	runHooks               []*commandHook
-	persistentPostRunHooks []*commandHook
-	postRunHooks           []*commandHook
-	helpHooks              []*commandHook
-)
+	cmd          *cobra.Command
+	phase        Phase
+	hook         func(cmd *cobra.Command, args []string) error
+	rewrite      func(cmd *cobra.Command, args []string) ([]string, error)
+	onError      func(cmd *cobra.Command, p Phase, err error) error
+	runOnHelp    bool
+	runOnVersion bool
+	persistent   bool
+	name         string
+	labels       []string
+	before       []string
+	after        []string
+	file         string
+	line         int
+}
 
-type HookOptions struct {
-	runOnHelp  bool
-	persistent bool
+// newCommandHook creates a hook for the command and records where it was registered
+func newCommandHook(c *cobra.Command, p Phase, h func(cmd *cobra.Command, args []string) error, opts HookOptions) *commandHook {
+	ch := &commandHook{
+		cmd:          c,
+		phase:        p,
+		hook:         h,
+		runOnHelp:    opts.runOnHelp,
+		runOnVersion: opts.runOnVersion,
+		persistent:   opts.persistent,
+		name:         opts.name,
+		labels:       opts.labels,
+		before:       opts.before,
+		after:        opts.after,
+	}
+	ch.file, ch.line = registrationSite()
+	return ch
 }
 
-func RunOnHelp(o *HookOptions) { o.runOnHelp = true }
+// Phase is a phase of a command's execution that hooks can be registered for.
+type Phase int
 
-func Persistent(o *HookOptions) { o.persistent = true }
+const (
+	PersistentPreRunPhase Phase = iota
+	PreRunPhase
+	RunPhase
+	PostRunPhase
+	PersistentPostRunPhase
+	// HelpPhase runs when help is invoked for the command
+	HelpPhase
+	// VersionPhase runs when the version flag is invoked for the command
+	VersionPhase
+	// FinallyPhase runs at the end of every execution through Execute, also
+	// when it failed
+	FinallyPhase
+	// ErrorPhase runs when the hooks of another phase fail, see OnError
+	ErrorPhase
+)
 
-// OnRun registers a Run hook onto the command.
-func (c *Command) OnRun(h func(cmd *cobra.Command, args []string) error) {
-	OnRun(c.Command, h)
+var phaseNames = [...]string{
+	PersistentPreRunPhase:  "PersistentPreRun",
+	PreRunPhase:            "PreRun",
+	RunPhase:               "Run",
+	PostRunPhase:           "PostRun",
+	PersistentPostRunPhase: "PersistentPostRun",
+	HelpPhase:              "Help",
+	VersionPhase:           "Version",
+	FinallyPhase:           "Finally",
+	ErrorPhase:             "Error",
 }
 
-// OnRun registers a Run hook onto the command.
-func OnRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error) {
-	// Register the hook
-	runHooks = append(runHooks, &commandHook{
-		cmd:  c,
-		hook: h,
-	})
-	if c.RunE != nil {
-		return
+const numPhases = Phase(len(phaseNames))
+
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return fmt.Sprintf("Phase(%d)", int(p))
 	}
-	c.RunE = func(cmd *cobra.Command, args []string) error {
-		// find and execute any registered Run hooks
-		for _, ch := range runHooks {
-			if ch.cmd == cmd {
-				if err := ch.hook(cmd, args); err != nil {
-					return err
-				}
-			}
+	return phaseNames[p]
+}
+
+// ParsePhase returns the phase with the name (e.g. "PersistentPreRun"), ignoring case.
+func ParsePhase(name string) (Phase, error) {
+	for p, n := range phaseNames {
+		if strings.EqualFold(n, name) {
+			return Phase(p), nil
 		}
-		return nil
 	}
+	return 0, fmt.Errorf("cobrahooks: unknown phase %q", name)
 }
 
-func runPreRunHooks(cmd *cobra.Command, args []string, isHelpRun bool) error {
-	for _, ch := range preRunHooks {
-		if ch.cmd == cmd && (!isHelpRun || ch.runOnHelp) {
-			if err := ch.hook(cmd, args); err != nil {
-				return err
-			}
-		}
+// MarshalText implements encoding.TextMarshaler.
+func (p Phase) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so phases can be read from configuration.
+func (p *Phase) UnmarshalText(text []byte) error {
+	phase, err := ParsePhase(string(text))
+	if err != nil {
+		return err
 	}
+	*p = phase
 	return nil
 }
 
-// OnPreRun registers a PreRun hook on the command.
-func (c *Command) OnPreRun(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
-	OnPreRun(c.Command, h, options...)
+type runMode int
+
+const (
+	normalRun runMode = iota
+	helpRun
+	versionRun
+)
+
+func (m runMode) String() string {
+	switch m {
+	case helpRun:
+		return "help"
+	case versionRun:
+		return "version"
+	}
+	return "run"
 }
 
-// OnPreRun registers a PreRun hook on the command.
-func OnPreRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
-	var opts HookOptions
-	for _, option := range options {
-		option(&opts)
+// hookChain returns the hooks of the phase that apply to the command in the order they are executed
+func hookChain(cmd *cobra.Command, p Phase, mode runMode) []*commandHook {
+	var chain []*commandHook
+	for _, ch := range registered.chain(cmd, p, mode) {
+		if ch.disabledReason(cmd) == "" {
+			chain = append(chain, ch)
+		}
 	}
-	if opts.persistent {
-		OnPersistentPreRun(c, h, options...)
-		return
+	return chain
+}
+
+// forEachCandidate calls fn for the hooks of the phase registered on the command
+// and its parents, in the order they are executed
+func forEachCandidate(cmd *cobra.Command, p Phase, fn func(ch *commandHook)) {
+	var cmds []*cobra.Command
+	for c := cmd; c != nil; c = c.Parent() {
+		cmds = append(cmds, c)
 	}
-	// Register the hook
-	preRunHooks = append(preRunHooks, &commandHook{
-		cmd:  c,
-		hook: h,
-	})
-	if opts.runOnHelp {
-		initHelpHooks(c)
+	if p == PersistentPreRunPhase {
+		// Run the hooks from parent to child
+		for i, j := 0, len(cmds)-1; i < j; i, j = i+1, j-1 {
+			cmds[i], cmds[j] = cmds[j], cmds[i]
+		}
 	}
-	if c.PreRunE != nil {
-		return
+	for _, c := range cmds {
+		for _, ch := range registered.commandHooks(c, p) {
+			fn(ch)
+		}
 	}
-	c.PreRunE = func(cmd *cobra.Command, args []string) error {
-		return runPreRunHooks(cmd, args, false)
+}
+
+// skipReason returns why the hook does not run for the command, or an empty string when it does
+func (ch *commandHook) skipReason(cmd *cobra.Command, mode runMode) string {
+	if reason := ch.chainSkipReason(cmd, mode); reason != "" {
+		return reason
 	}
+	return ch.disabledReason(cmd)
 }
 
-// OnPostRun registers a PostRun hook on the command.
-func (c *Command) OnPostRun(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
-	OnPostRun(c.Command, h, options...)
+// chainSkipReason returns why the hook is not part of the command's chain for
+// the run mode, or an empty string when it is
+func (ch *commandHook) chainSkipReason(cmd *cobra.Command, mode runMode) string {
+	if ch.cmd != cmd && !ch.persistent {
+		return "not persistent"
+	}
+	if ch.phase == PersistentPreRunPhase || ch.phase == PreRunPhase {
+		if mode == helpRun && !ch.runOnHelp {
+			return "not registered with RunOnHelp"
+		}
+		if mode == versionRun && !ch.runOnVersion {
+			return "not registered with RunOnVersion"
+		}
+	}
+	return ""
 }
 
-// OnPostRun registers a PostRun hook on the command.
-func OnPostRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
-	var opts HookOptions
-	for _, option := range options {
-		option(&opts)
+type dispatcher struct {
+	cmd   *cobra.Command
+	phase Phase
+}
+
+// dispatchers keeps track of the command fields that are set by this package
+var dispatchers = make(map[dispatcher]bool)
+
+func setDispatcher(c *cobra.Command, p Phase) {
+	dispatchers[dispatcher{c, p}] = true
+}
+
+// isDispatcher reports whether the command's field for the phase is set by this package
+func isDispatcher(c *cobra.Command, p Phase) bool {
+	return dispatchers[dispatcher{c, p}]
+}
+
+// installDispatcher sets the command's field for the phase to run the registered hooks,
+// unless the field is already defined
+func installDispatcher(c *cobra.Command, p Phase) {
+	d := func(cmd *cobra.Command, args []string) error {
+		return dispatch(cmd, args, p)
 	}
-	if opts.persistent {
-		OnPersistentPostRun(c, h)
+	switch p {
+	case PersistentPreRunPhase:
+		if c.PersistentPreRunE != nil {
+			return
+		}
+		c.PersistentPreRunE = d
+	case PreRunPhase:
+		if c.PreRunE != nil {
+			return
+		}
+		c.PreRunE = d
+	case RunPhase:
+		if c.RunE != nil {
+			return
+		}
+		c.RunE = d
+	case PostRunPhase:
+		if c.PostRunE != nil {
+			return
+		}
+		c.PostRunE = d
+	case PersistentPostRunPhase:
+		if c.PersistentPostRunE != nil {
+			return
+		}
+		c.PersistentPostRunE = d
+	default:
 		return
 	}
-	// Register the hook
-	postRunHooks = append(postRunHooks, &commandHook{
-		cmd:  c,
-		hook: h,
-	})
-	if c.PostRunE != nil {
-		return
+	setDispatcher(c, p)
+}
+
+// register adds the hook to the registered hooks and integrates it with the command
+func register(ch *commandHook) {
+	c := ch.cmd
+	registered.add(ch)
+	if ch.runOnHelp || ch.phase == HelpPhase {
+		initHelpHooks(c)
 	}
-	c.PostRunE = func(cmd *cobra.Command, args []string) error {
-		// find and execute any registered PreRun hooks
-		for _, ch := range postRunHooks {
-			if ch.cmd == cmd {
-				if err := ch.hook(cmd, args); err != nil {
-					return err
-				}
-			}
+	if ch.runOnVersion || ch.phase == VersionPhase {
+		initVersionHooks(c)
+	}
+	installDispatcher(c, ch.phase)
+}
+
+// dispatch runs the hooks of the phase when cobra executes the command
+func dispatch(cmd *cobra.Command, args []string, p Phase) error {
+	e := currentExecution(cmd)
+	if e.explained {
+		return nil
+	}
+	if isExplaining() {
+		// The execution succeeds without running the hooks
+		e.explained = true
+		explain(cmd)
+		return nil
+	}
+	if e.redirected {
+		if debug := debugWriter(); debug != nil {
+			debugf(debug, "%s hooks for %q skipped by a redirect", p, cmd.CommandPath())
+		}
+		return nil
+	}
+	if e.skipRun && p <= RunPhase {
+		if debug := debugWriter(); debug != nil {
+			debugf(debug, "%s hooks for %q skipped by ErrSkipRun", p, cmd.CommandPath())
 		}
 		return nil
 	}
+	// Continue with the arguments rewritten by earlier hooks
+	if e.argsRewritten {
+		args = e.args
+	}
+	args, err := runPhaseHooks(cmd, args, p, normalRun)
+	e.args, e.argsRewritten = args, true
+	if err == ErrSkipRun {
+		e.skipRun = true
+		return nil
+	}
+	if r, ok := asRedirect(err); ok && p <= RunPhase {
+		e.redirected = true
+		err = redirect(e, r)
+	} else if err != nil {
+		err = runErrorHooks(cmd, p, err)
+	}
+	if err != nil {
+		// Errors of hooks are runtime errors, the usage does not help
+		silenceUsage(cmd, err)
+	}
+	return err
 }
 
-func runPersistentPreRunHooks(cmd *cobra.Command, args []string, isHelpRun bool) error {
-	var runChain []*commandHook
-	// Walk up the command chain
-	for p := cmd; p != nil; p = p.Parent() {
-		// find any registered PersistentPreRun hooks and build the run chain
-		for _, ch := range persistentPreRunHooks {
-			if ch.cmd == p && (!isHelpRun || ch.runOnHelp) {
-				runChain = append(runChain, &commandHook{
-					hook: ch.hook,
-				})
+// runPhaseHooks runs the hooks of the phase that apply to the command and returns
+// the arguments as rewritten by the hooks. ErrSkipRun is returned when a hook
+// returned it, the hooks of later phases have to decide what to do with it.
+func runPhaseHooks(cmd *cobra.Command, args []string, p Phase, mode runMode) ([]string, error) {
+	debug := debugWriter()
+	if debug != nil {
+		debugCandidates(debug, cmd, p, mode)
+	}
+	e := currentExecution(cmd)
+	e.phase, e.mode = p, mode
+	for _, ch := range registered.chain(cmd, p, mode) {
+		if ch.disabledReason(cmd) != "" {
+			continue
+		}
+		if debug != nil {
+			debugf(debug, "  run  %s", ch.describe())
+		}
+		newArgs, err := callHook(ch, cmd, args)
+		if err != nil {
+			if errors.Is(err, ErrSkipRun) || errors.Is(err, ErrStopHooks) {
+				if debug != nil {
+					debugf(debug, "  stop %s: %v", ch.describe(), err)
+				}
+				if errors.Is(err, ErrSkipRun) {
+					return args, ErrSkipRun
+				}
+				return args, nil
+			}
+			if debug != nil {
+				debugf(debug, "  fail %s: %v", ch.describe(), err)
 			}
+			return args, err
 		}
+		args = newArgs
 	}
-	// Run the command chain hooks from parent to child
-	for i := len(runChain) - 1; i >= 0; i-- {
-		if err := runChain[i].hook(cmd, args); err != nil {
+	return args, nil
+}
+
+// runModeHooks runs the PersistentPreRun and PreRun hooks for the run mode,
+// followed by the hooks of the phase
+func runModeHooks(cmd *cobra.Command, args []string, p Phase, mode runMode) error {
+	// Help and version are not executions of the command, start a new one
+	// instead of continuing one from an earlier execution
+	current = newExecution(cmd)
+	var err error
+	for _, pp := range []Phase{PersistentPreRunPhase, PreRunPhase} {
+		args, err = runPhaseHooks(cmd, args, pp, mode)
+		// Redirects only apply to executions
+		if _, ok := asRedirect(err); ok || err == ErrSkipRun {
+			break
+		}
+		if err != nil {
 			return err
 		}
 	}
-	return nil
+	_, err = runPhaseHooks(cmd, args, p, mode)
+	if _, ok := asRedirect(err); ok || err == ErrSkipRun {
+		return nil
+	}
+	return err
 }
 
-// OnPersistentPostRun registers a PreRun hook on the command and all of its childs
-func (c *Command) OnPersistentPreRun(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
-	OnPersistentPreRun(c.Command, h, options...)
+type HookOptions struct {
+	runOnHelp    bool
+	runOnVersion bool
+	persistent   bool
+	name         string
+	labels       []string
+	before       []string
+	after        []string
 }
 
-// OnPersistentPostRun registers a PreRun hook on the command and all of its childs
-func OnPersistentPreRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+func RunOnHelp(o *HookOptions) { o.runOnHelp = true }
+
+func RunOnVersion(o *HookOptions) { o.runOnVersion = true }
+
+func Persistent(o *HookOptions) { o.persistent = true }
+
+// On registers a hook for the phase onto the command.
+func (c *Command) On(p Phase, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	On(c.Command, p, h, options...)
+}
+
+// On registers a hook for the phase onto the command. The On* functions
+// (OnRun, OnPreRun etc.) are shorthands for it.
+//
+// The PersistentPreRun and PersistentPostRun hooks also run for the childs of
+// the command. The Persistent option makes the PreRun and PostRun hooks
+// PersistentPreRun and PersistentPostRun hooks.
+func On(c *cobra.Command, p Phase, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	if p < 0 || int(p) >= len(phaseNames) {
+		panic(fmt.Sprintf("cobrahooks: unknown phase %d", int(p)))
+	}
+	if p == ErrorPhase {
+		panic("cobrahooks: use OnError to register Error hooks")
+	}
+	p, opts := hookOptions(p, options)
+	register(newCommandHook(c, p, h, opts))
+}
+
+// hookOptions applies the options for a hook of the phase and returns the
+// phase the hook is registered for
+func hookOptions(p Phase, options []func(*HookOptions)) (Phase, HookOptions) {
 	var opts HookOptions
 	for _, option := range options {
 		option(&opts)
 	}
-	// Register the hook
-	// Prepend to the array to ensure hooks are executed
-	// in the same order as how they are registered for the command
-	// (since the runChain will be executed in reversed order from parent to child)
-	persistentPreRunHooks = append([]*commandHook{
-		&commandHook{
-			cmd:       c,
-			hook:      h,
-			runOnHelp: opts.runOnHelp,
-		},
-	}, persistentPreRunHooks...)
-
-	if opts.runOnHelp {
-		initHelpHooks(c)
+	switch {
+	case p == PersistentPreRunPhase || p == PersistentPostRunPhase || p == FinallyPhase || p == ErrorPhase:
+		opts.persistent = true
+	case p == PreRunPhase && opts.persistent:
+		p = PersistentPreRunPhase
+	case p == PostRunPhase && opts.persistent:
+		p = PersistentPostRunPhase
 	}
+	return p, opts
+}
 
-	if c.PersistentPreRunE != nil {
-		return
-	}
-	c.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
-		return runPersistentPreRunHooks(cmd, args, false)
-	}
+// OnRun registers a Run hook onto the command.
+func (c *Command) OnRun(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	OnRun(c.Command, h, options...)
+}
+
+// OnRun registers a Run hook onto the command.
+func OnRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	On(c, RunPhase, h, options...)
+}
+
+// OnPreRun registers a PreRun hook on the command.
+func (c *Command) OnPreRun(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	OnPreRun(c.Command, h, options...)
+}
+
+// OnPreRun registers a PreRun hook on the command.
+func OnPreRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	On(c, PreRunPhase, h, options...)
+}
+
+// OnPostRun registers a PostRun hook on the command.
+func (c *Command) OnPostRun(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	OnPostRun(c.Command, h, options...)
+}
+
+// OnPostRun registers a PostRun hook on the command.
+func OnPostRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	On(c, PostRunPhase, h, options...)
+}
+
+// OnPersistentPostRun registers a PreRun hook on the command and all of its childs
+func (c *Command) OnPersistentPreRun(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	OnPersistentPreRun(c.Command, h, options...)
+}
+
+// OnPersistentPostRun registers a PreRun hook on the command and all of its childs
+func OnPersistentPreRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	On(c, PersistentPreRunPhase, h, options...)
 }
 
 // OnPersistentPostRun registers a PostRun hook on the command and all of its childs
-func (c *Command) OnPersistentPostRun(h func(cmd *cobra.Command, args []string) error) {
-	OnPersistentPostRun(c.Command, h)
+func (c *Command) OnPersistentPostRun(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	OnPersistentPostRun(c.Command, h, options...)
 }
 
 // OnPersistentPostRun registers a PostRun hook on the command and all of its childs
-func OnPersistentPostRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error) {
-	// Register the hook
-	persistentPostRunHooks = append(persistentPostRunHooks, &commandHook{
-		cmd:  c,
-		hook: h,
-	})
-	if c.PersistentPostRunE != nil {
-		return
-	}
-	c.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
-		// Walk up the command chain
-		for p := cmd; p != nil; p = p.Parent() {
-			// find and execute any registered PostRun hooks
-			for _, ch := range persistentPostRunHooks {
-				if ch.cmd == p {
-					if err := ch.hook(cmd, args); err != nil {
-						return err
-					}
-				}
-			}
-		}
-		return nil
-	}
+func OnPersistentPostRun(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	On(c, PersistentPostRunPhase, h, options...)
 }
 
 // OnHelp registers a hook when help is invoked for the command
@@ -240,50 +493,62 @@ func (c *Command) OnHelp(h func(cmd *cobra.Command, args []string) error, option
 	OnHelp(c.Command, h, options...)
 }
 
-var isHelpHooksInitialized bool
+var helpHooksInitialized = make(map[*cobra.Command]bool)
 
 func initHelpHooks(c *cobra.Command) {
-	if isHelpHooksInitialized {
-		return
-	}
 	r := c.Root()
-	if r == nil {
+	if r == nil || helpHooksInitialized[r] {
 		return
 	}
 	// Integrate with the root command
 	helpFunc := r.HelpFunc()
 	r.SetHelpFunc(func(cmd *cobra.Command, args []string) {
-		if err := runPersistentPreRunHooks(cmd, args, true); err != nil {
-			return
-		}
-		if err := runPreRunHooks(cmd, args, true); err != nil {
+		if err := runHelpHooks(cmd, args); err != nil {
 			return
 		}
-		for p, isParent := cmd, false; p != nil; p, isParent = p.Parent(), true {
-			for _, ch := range helpHooks {
-				if ch.cmd == p && (!isParent || ch.persistent == true) {
-					if err := ch.hook(cmd, args); err != nil {
-						return
-					}
-				}
-			}
-		}
 		helpFunc(cmd, args)
 	})
-	isHelpHooksInitialized = true
+	helpHooksInitialized[r] = true
+}
+
+// runHelpHooks runs all hooks that should run when help is invoked for the command
+func runHelpHooks(cmd *cobra.Command, args []string) error {
+	return runModeHooks(cmd, args, HelpPhase, helpRun)
 }
 
 // OnHelp registers a hook for when help is invoked.
 func OnHelp(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
-	initHelpHooks(c)
-	var opts HookOptions
-	for _, option := range options {
-		option(&opts)
+	On(c, HelpPhase, h, options...)
+}
+
+// OnVersion registers a hook for when the version flag is invoked for the command
+func (c *Command) OnVersion(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	OnVersion(c.Command, h, options...)
+}
+
+var versionHooksInitialized = make(map[*cobra.Command]bool)
+
+func initVersionHooks(c *cobra.Command) {
+	r := c.Root()
+	if r == nil || versionHooksInitialized[r] {
+		return
 	}
-	// Register the hook
-	helpHooks = append(helpHooks, &commandHook{
-		cmd:        c,
-		hook:       h,
-		persistent: opts.persistent,
-	})
+	// Cobra prints the version through the version template,
+	// prefix it with a template function that runs the hooks
+	r.SetVersionTemplate("{{cobrahooksRunVersionHooks .}}" + r.VersionTemplate())
+	versionHooksInitialized[r] = true
+}
+
+// runVersionHooks runs all hooks that should run when the version flag is invoked for the command
+func runVersionHooks(cmd *cobra.Command, args []string) error {
+	return runModeHooks(cmd, args, VersionPhase, versionRun)
+}
+
+// OnVersion registers a hook for when the version flag is invoked.
+// The hooks run before the version template is rendered, so they can enrich
+// the version output (e.g. by updating the command's Version field).
+// Note that setting a version template on the root command afterwards
+// disables the hooks.
+func OnVersion(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	On(c, VersionPhase, h, options...)
 }
@@ -0,0 +1,134 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package cobrahookstest provides helpers to test commands and the hooks
+// registered on them with cobrahooks.
+//
+//	func TestConfigHook(t *testing.T) {
+//	    h := cobrahookstest.New(t)
+//	    root := newRootCmd() // registers the hooks
+//	    h.Env["MYAPP_CONFIG"] = "testdata/config.yaml"
+//
+//	    res := h.Execute(root, "serve", "--port=0")
+//	    if res.Err != nil {
+//	        t.Fatal(res.Err)
+//	    }
+//	    h.Recorder.Assert(t, "PersistentPreRun load-config", "Run serve")
+//	}
+package cobrahookstest
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+)
+
+// Harness executes command trees with captured output, fake input and
+// environment, and records the hooks that run.
+type Harness struct {
+	t testing.TB
+	// Recorder records the hooks that ran during the last execution
+	Recorder *Recorder
+	// Stdin is the input of the executions
+	Stdin string
+	// Env holds the environment variables to set during the executions
+	Env map[string]string
+}
+
+// Result is the result of an execution.
+type Result struct {
+	// Command is the command that was executed
+	Command *cobra.Command
+	Stdout  string
+	Stderr  string
+	Err     error
+}
+
+// New returns a harness for the test. The hooks registered before are
+// isolated from the test, and the hooks registered during the test are
+// dropped when it finishes (see cobrahooks.Isolate), so create the harness
+// before building the command tree.
+func New(t testing.TB) *Harness {
+	restore := cobrahooks.Isolate()
+	t.Cleanup(restore)
+	h := &Harness{
+		t:        t,
+		Recorder: &Recorder{},
+		Env:      make(map[string]string),
+	}
+	cobrahooks.AddObserver(h.Recorder)
+	return h
+}
+
+// Execute executes the root command with the arguments through
+// cobrahooks.ExecuteC and returns the result. The recorder is reset before
+// the execution.
+func (h *Harness) Execute(root *cobra.Command, args ...string) *Result {
+	h.t.Helper()
+	restoreEnv := h.setenv()
+	defer restoreEnv()
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	root.SetIn(strings.NewReader(h.Stdin))
+	if args == nil {
+		args = []string{}
+	}
+	cobrahooks.SetArgs(root, args)
+
+	h.Recorder.Reset()
+	c, err := cobrahooks.ExecuteC(root)
+	return &Result{
+		Command: c,
+		Stdout:  stdout.String(),
+		Stderr:  stderr.String(),
+		Err:     err,
+	}
+}
+
+// setenv sets the environment variables of the harness and returns a
+// function that restores their previous values
+func (h *Harness) setenv() (restore func()) {
+	prev := make(map[string]*string)
+	for k, v := range h.Env {
+		if old, ok := os.LookupEnv(k); ok {
+			prev[k] = &old
+		} else {
+			prev[k] = nil
+		}
+		if err := os.Setenv(k, v); err != nil {
+			h.t.Fatalf("cobrahookstest: %v", err)
+		}
+	}
+	return func() {
+		for k, v := range prev {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+// AssertContains fails the test if got does not contain expected.
+func AssertContains(t testing.TB, got, expected string) {
+	t.Helper()
+	if !strings.Contains(got, expected) {
+		t.Errorf("Expected to contain: \n %v\nGot:\n %v\n", expected, got)
+	}
+}
+
+// AssertOmits fails the test if got contains expected.
+func AssertOmits(t testing.TB, got, expected string) {
+	t.Helper()
+	if strings.Contains(got, expected) {
+		t.Errorf("Expected to not contain: \n %v\nGot: %v", expected, got)
+	}
+}
@@ -0,0 +1,85 @@
+package cobrahookstest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+)
+
+func TestHarness(t *testing.T) {
+	h := New(t)
+	h.Stdin = "input"
+	h.Env["COBRAHOOKSTEST_NAME"] = "env"
+
+	rootCmd := &cobra.Command{Use: "root"}
+	childCmd := &cobra.Command{Use: "child"}
+	rootCmd.AddCommand(childCmd)
+
+	cobrahooks.OnPersistentPreRun(rootCmd, func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(cmd.ErrOrStderr(), "name:", os.Getenv("COBRAHOOKSTEST_NAME"))
+		return nil
+	}, cobrahooks.Name("greet"))
+	cobrahooks.OnRun(childCmd, func(cmd *cobra.Command, args []string) error {
+		in, err := ioutil.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "stdin:", string(in))
+		return nil
+	}, cobrahooks.Name("read"))
+	cobrahooks.OnPostRun(childCmd, func(cmd *cobra.Command, args []string) error {
+		return errors.New("failed")
+	}, cobrahooks.Name("fail"))
+
+	res := h.Execute(rootCmd, "child")
+	if res.Err == nil || res.Command != childCmd {
+		t.Errorf("Expected child to fail, got %v, %v", res.Command, res.Err)
+	}
+	AssertContains(t, res.Stderr, "name: env\n")
+	AssertContains(t, res.Stdout, "stdin: input\n")
+	// Cobra prints the errors to the output
+	AssertContains(t, res.Stdout, `Error: hook "fail": failed`)
+	AssertOmits(t, res.Stdout, "name: env")
+	h.Recorder.Assert(t,
+		"PersistentPreRun greet",
+		"Run read",
+		"PostRun fail",
+	)
+	calls := h.Recorder.Calls()
+	if calls[0].Owner != "root" || calls[0].Command != "root child" || calls[2].Err == nil {
+		t.Errorf("Unexpected calls: %+v", calls)
+	}
+	if _, ok := os.LookupEnv("COBRAHOOKSTEST_NAME"); ok {
+		t.Errorf("Expected the environment to be restored")
+	}
+}
+
+func TestIsolation(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root", Run: func(_ *cobra.Command, _ []string) {}}
+	var ran bool
+	cobrahooks.OnPreRun(rootCmd, func(_ *cobra.Command, _ []string) error {
+		ran = true
+		return nil
+	})
+	// Hooks registered outside of the harness do not run
+	t.Run("isolated", func(t *testing.T) {
+		h := New(t)
+		h.Execute(rootCmd)
+		if ran {
+			t.Errorf("Expected the hook registered outside the harness not to run")
+		}
+		h.Recorder.Assert(t)
+	})
+	rootCmd.SetArgs([]string{})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Errorf("Expected the hook to run once the harness is cleaned up")
+	}
+}
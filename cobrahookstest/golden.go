@@ -0,0 +1,118 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahookstest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+)
+
+// update is the test flag to regenerate the golden files, namespaced so it
+// does not conflict with the -update flags of the tests
+var update = flag.Bool("cobrahookstest.update", false, "update the golden files of AssertHelp")
+
+// AssertHelp renders the help of every command in the tree returned by
+// newRoot as --help shows it, with the hooks registered with RunOnHelp and
+// the OnHelp hooks applied, and compares it to the golden files in dir (e.g.
+// "testdata/help"), one per command named after the command path (e.g.
+// "root_child.golden"). Every help is rendered on a new tree, so the hooks
+// of one command do not affect the help of the others.
+// Run the tests with the -cobrahookstest.update flag to write the golden files.
+func AssertHelp(t testing.TB, newRoot func() *cobra.Command, dir string) {
+	t.Helper()
+	var paths []string
+	walkCommands(newRoot(), func(c *cobra.Command) {
+		paths = append(paths, c.CommandPath())
+	})
+
+	if *update {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("cobrahookstest: %v", err)
+		}
+	}
+	for _, cmdPath := range paths {
+		help, err := renderHelp(newRoot(), cmdPath)
+		if err != nil {
+			t.Errorf("Help of %q failed: %v", cmdPath, err)
+			continue
+		}
+		path := filepath.Join(dir, strings.Replace(cmdPath, " ", "_", -1)+".golden")
+		if *update {
+			if err := ioutil.WriteFile(path, []byte(help), 0644); err != nil {
+				t.Fatalf("cobrahookstest: %v", err)
+			}
+			continue
+		}
+		golden, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Errorf("Help of %q: %v (run the tests with -cobrahookstest.update to create it)", cmdPath, err)
+			continue
+		}
+		if string(golden) != help {
+			t.Errorf("Help of %q differs from %s:\n%s", cmdPath, path, diff(string(golden), help))
+		}
+	}
+}
+
+// walkCommands calls fn for the command and all of its childs, parents first
+func walkCommands(c *cobra.Command, fn func(*cobra.Command)) {
+	fn(c)
+	for _, child := range c.Commands() {
+		walkCommands(child, fn)
+	}
+}
+
+// renderHelp executes the root command to show the help of the command with the path
+func renderHelp(root *cobra.Command, cmdPath string) (string, error) {
+	var c *cobra.Command
+	walkCommands(root, func(cmd *cobra.Command) {
+		if cmd.CommandPath() == cmdPath {
+			c = cmd
+		}
+	})
+	if c == nil {
+		return "", fmt.Errorf("the new tree has no %q command", cmdPath)
+	}
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetErr(buf)
+	if c.DisableFlagParsing {
+		// The --help flag would be passed to the command as an argument
+		c.InitDefaultHelpFlag()
+		err := c.Help()
+		return buf.String(), err
+	}
+	args := strings.Fields(cmdPath)[1:]
+	cobrahooks.SetArgs(root, append(args, "--help"))
+	err := root.Execute()
+	return buf.String(), err
+}
+
+// diff describes the first line that differs
+func diff(expected, got string) string {
+	e, g := strings.Split(expected, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(e) || i < len(g); i++ {
+		var el, gl string
+		if i < len(e) {
+			el = e[i]
+		}
+		if i < len(g) {
+			gl = g[i]
+		}
+		if el != gl {
+			return fmt.Sprintf("line %d:\n  expected: %s\n  got:      %s", i+1, el, gl)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package cobrahookstest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+)
+
+// The test binaries of the users commonly define their own -update flag
+var _ = flag.Bool("update", false, "update the golden files of the tests")
+
+func newHelpTree() *cobra.Command {
+	rootCmd := &cobra.Command{Use: "root", Short: "The root command"}
+	childCmd := &cobra.Command{Use: "child", Short: "The child command", Run: func(_ *cobra.Command, _ []string) {}}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.PersistentFlags().String("region", "", "the region")
+	cobrahooks.OnPersistentPreRun(rootCmd, func(cmd *cobra.Command, args []string) error {
+		cmd.Flags().Lookup("region").DefValue = "eu-west-1"
+		return nil
+	}, cobrahooks.RunOnHelp)
+	cobrahooks.OnHelp(childCmd, func(cmd *cobra.Command, args []string) error {
+		cmd.Long = "The child command, with a dynamic description."
+		return nil
+	})
+	return rootCmd
+}
+
+func TestAssertHelp(t *testing.T) {
+	New(t)
+	AssertHelp(t, newHelpTree, "testdata/help")
+}
+
+func TestAssertHelpNewTrees(t *testing.T) {
+	New(t)
+	var roots []*cobra.Command
+	AssertHelp(t, func() *cobra.Command {
+		rootCmd := newHelpTree()
+		roots = append(roots, rootCmd)
+		return rootCmd
+	}, "testdata/help")
+	// One tree to find the commands and one per command
+	if len(roots) != 3 {
+		t.Fatalf("Expected 3 trees, got %d", len(roots))
+	}
+	if roots[0].OutOrStdout() != os.Stdout {
+		t.Error("Expected the first tree to be left alone")
+	}
+}
+
+// fakeT records the failures of a test
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertHelpMismatch(t *testing.T) {
+	New(t)
+	ft := &fakeT{TB: t}
+	AssertHelp(ft, func() *cobra.Command {
+		rootCmd := newHelpTree()
+		rootCmd.Commands()[0].Short = "Changed"
+		return rootCmd
+	}, "testdata/help")
+	if len(ft.errors) != 1 {
+		t.Fatalf("Expected 1 failure, got %q", ft.errors)
+	}
+	AssertContains(t, ft.errors[0], `Help of "root" differs from testdata/help/root.golden:`)
+	AssertContains(t, ft.errors[0], "got:        child       Changed")
+}
@@ -0,0 +1,76 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahookstest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bartdeboer/cobrahooks"
+)
+
+// Call records the execution of a hook.
+type Call struct {
+	Phase cobrahooks.Phase
+	// Command is the path of the command being executed
+	Command string
+	// Owner is the path of the command the hook is registered on
+	Owner string
+	// Hook is the name of the hook or else the name of its function
+	Hook string
+	Err  error
+}
+
+// String returns the phase and the hook, e.g. "PersistentPreRun load-config".
+func (c Call) String() string {
+	return fmt.Sprintf("%s %s", c.Phase, c.Hook)
+}
+
+// Recorder is a cobrahooks.HookObserver that records the hooks that ran.
+type Recorder struct {
+	calls []Call
+}
+
+// BeforeHook implements cobrahooks.HookObserver.
+func (r *Recorder) BeforeHook(e cobrahooks.HookEvent) {}
+
+// AfterHook implements cobrahooks.HookObserver.
+func (r *Recorder) AfterHook(e cobrahooks.HookEvent) {
+	name := e.Hook.Name
+	if name == "" {
+		name = e.Hook.Func
+	}
+	r.calls = append(r.calls, Call{
+		Phase:   e.Hook.Phase,
+		Command: e.Command.CommandPath(),
+		Owner:   e.Hook.Command.CommandPath(),
+		Hook:    name,
+		Err:     e.Err,
+	})
+}
+
+// Calls returns the recorded hook executions in the order they ran.
+func (r *Recorder) Calls() []Call {
+	return r.calls
+}
+
+// Reset clears the recorded hook executions.
+func (r *Recorder) Reset() {
+	r.calls = nil
+}
+
+// Assert fails the test unless exactly the expected hooks ran, in order.
+// The hooks are described as "<phase> <name>", see Call.String.
+func (r *Recorder) Assert(t testing.TB, expected ...string) {
+	t.Helper()
+	got := make([]string, len(r.calls))
+	for i, c := range r.calls {
+		got[i] = c.String()
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected the hooks:\n  %s\nGot:\n  %s", strings.Join(expected, "\n  "), strings.Join(got, "\n  "))
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahookstest
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+)
+
+// Replay executes the invocations recorded with cobrahooks.Record in the
+// file at path, each against a new tree returned by newRoot, and fails the
+// test when the output or the error differ from the recording. The recorded
+// environment variables and input are set for the executions.
+func (h *Harness) Replay(path string, newRoot func() *cobra.Command) {
+	h.t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		h.t.Fatalf("cobrahookstest: %v", err)
+	}
+	defer f.Close()
+	recs, err := cobrahooks.ReadRecordings(f)
+	if err != nil {
+		h.t.Fatalf("cobrahookstest: %s: %v", path, err)
+	}
+
+	env, stdin := h.Env, h.Stdin
+	defer func() {
+		h.Env, h.Stdin = env, stdin
+	}()
+	for i, rec := range recs {
+		h.Env = make(map[string]string)
+		for k, v := range env {
+			h.Env[k] = v
+		}
+		for k, v := range rec.Env {
+			h.Env[k] = v
+		}
+		h.Stdin = ""
+		if rec.Stdin != nil {
+			h.Stdin = *rec.Stdin
+		}
+
+		res := h.Execute(newRoot(), rec.Argv...)
+		name := strings.Join(rec.Argv, " ")
+		if res.Stdout != rec.Stdout {
+			h.t.Errorf("Replay %d (%q): stdout differs:\n%s", i+1, name, diff(rec.Stdout, res.Stdout))
+		}
+		if res.Stderr != rec.Stderr {
+			h.t.Errorf("Replay %d (%q): stderr differs:\n%s", i+1, name, diff(rec.Stderr, res.Stderr))
+		}
+		var errStr string
+		if res.Err != nil {
+			errStr = res.Err.Error()
+		}
+		if errStr != rec.Error {
+			h.t.Errorf("Replay %d (%q): expected error %q, got %q", i+1, name, rec.Error, errStr)
+		}
+	}
+}
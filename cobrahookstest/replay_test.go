@@ -0,0 +1,58 @@
+package cobrahookstest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bartdeboer/cobrahooks"
+	"github.com/spf13/cobra"
+)
+
+func newGreetTree(greeting string) *cobra.Command {
+	rootCmd := &cobra.Command{Use: "root"}
+	greetCmd := &cobra.Command{Use: "greet"}
+	rootCmd.AddCommand(greetCmd)
+	cobrahooks.OnRun(greetCmd, func(cmd *cobra.Command, args []string) error {
+		name, err := ioutil.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s from %s\n", greeting, strings.TrimSpace(string(name)), os.Getenv("COBRAHOOKSTEST_PLACE"))
+		return nil
+	})
+	return rootCmd
+}
+
+func TestReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobrahookstest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "record.jsonl")
+
+	h := New(t)
+	h.Stdin = "alice"
+	h.Env["COBRAHOOKSTEST_PLACE"] = "home"
+	rootCmd := newGreetTree("Hello")
+	cobrahooks.Record(rootCmd, path, cobrahooks.RecordOptions{Env: []string{"COBRAHOOKSTEST_PLACE"}})
+	res := h.Execute(rootCmd, "greet")
+	AssertContains(t, res.Stdout, "Hello alice from home")
+	h.Execute(rootCmd, "unknown")
+
+	h = New(t)
+	h.Replay(path, func() *cobra.Command { return newGreetTree("Hello") })
+
+	ft := &fakeT{TB: t}
+	h = New(ft)
+	h.Replay(path, func() *cobra.Command { return newGreetTree("Hi") })
+	if len(ft.errors) != 1 {
+		t.Fatalf("Expected 1 failure, got %q", ft.errors)
+	}
+	AssertContains(t, ft.errors[0], `Replay 1 ("greet"): stdout differs:`)
+	AssertContains(t, ft.errors[0], "got:      Hi alice from home")
+}
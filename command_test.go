@@ -401,3 +401,70 @@ func TestHelpCommandExecutedOnChild(t *testing.T) {
 	checkStringContains(t, output2, "(default \"grandchild new default value\")")
 	checkStringContains(t, output2, "(default \"grandchild help test value\")")
 }
+
+func TestHelpHooksOnSeveralRoots(t *testing.T) {
+	for _, name := range []string{"first", "second"} {
+		rootCmd := &Command{&cobra.Command{Use: name, Run: emptyRun}}
+		rootCmd.OnHelp(func(cmd *cobra.Command, args []string) error {
+			cmd.OutOrStdout().Write([]byte("Hello from the help hook of " + cmd.Name() + " "))
+			return nil
+		})
+
+		output, err := executeCommand(rootCmd.Command, "--help")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		checkStringContains(t, output, "Hello from the help hook of "+name)
+	}
+}
+
+func TestOn(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	childCmd := &Command{&cobra.Command{Use: "child"}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	var ran []string
+	// Register the hooks from configuration
+	config := []struct {
+		cmd   *Command
+		phase string
+		name  string
+	}{
+		{rootCmd, "PersistentPreRun", "persPre"},
+		{childCmd, "prerun", "pre"},
+		{childCmd, "Run", "run"},
+		{rootCmd, "PersistentPostRun", "persPost"},
+	}
+	for _, c := range config {
+		p, err := ParsePhase(c.phase)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		name := c.name
+		c.cmd.On(p, func(_ *cobra.Command, _ []string) error {
+			ran = append(ran, name)
+			return nil
+		})
+	}
+	// The Persistent option makes it a PersistentPostRun hook
+	On(rootCmd.Command, PostRunPhase, func(_ *cobra.Command, _ []string) error {
+		ran = append(ran, "post")
+		return nil
+	}, Persistent)
+
+	if _, err := executeCommand(rootCmd.Command, "child"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	expected := "persPre pre run persPost post"
+	if got := strings.Join(ran, " "); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	if _, err := ParsePhase("Teardown"); err == nil {
+		t.Errorf("Expected an error for an unknown phase")
+	}
+	var p Phase
+	if err := p.UnmarshalText([]byte("Help")); err != nil || p != HelpPhase {
+		t.Errorf("Expected HelpPhase, got %v, %v", p, err)
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"unsafe"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	contextType       = reflect.TypeOf((*context.Context)(nil)).Elem()
+	flagErrorFuncType = reflect.TypeOf((func(*cobra.Command, error) error)(nil))
+)
+
+// setContext sets the context of the command without executing it, cobra
+// only sets it through ExecuteContext
+func setContext(c *cobra.Command, ctx context.Context) error {
+	f, err := commandField(c, "ctx", contextType)
+	if err != nil {
+		return err
+	}
+	v := reflect.Zero(contextType)
+	if ctx != nil {
+		v = reflect.ValueOf(ctx)
+	}
+	f.Set(v)
+	return nil
+}
+
+// ownFlagErrorFunc returns the flag error function set on the command
+// itself, cobra only returns the one it inherits
+func ownFlagErrorFunc(c *cobra.Command) (func(*cobra.Command, error) error, error) {
+	f, err := commandField(c, "flagErrorFunc", flagErrorFuncType)
+	if err != nil {
+		return nil, err
+	}
+	return f.Interface().(func(*cobra.Command, error) error), nil
+}
+
+// commandField returns the settable private field of the command. It fails
+// when cobra no longer has the field, rather than silently doing nothing.
+func commandField(c *cobra.Command, name string, typ reflect.Type) (reflect.Value, error) {
+	f := reflect.ValueOf(c).Elem().FieldByName(name)
+	if !f.IsValid() || f.Type() != typ {
+		return reflect.Value{}, fmt.Errorf("cobrahooks: unsupported cobra version, cobra.Command has no %s field of type %s", name, typ)
+	}
+	return reflect.NewAt(typ, unsafe.Pointer(f.UnsafeAddr())).Elem(), nil
+}
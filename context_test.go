@@ -0,0 +1,28 @@
+package cobrahooks
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCommandField(t *testing.T) {
+	cmd := &cobra.Command{Use: "root"}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	if err := setContext(cmd, ctx); err != nil || cmd.Context() != ctx {
+		t.Errorf("Expected the context to be set, got %v", err)
+	}
+	if f, err := ownFlagErrorFunc(cmd); err != nil || f != nil {
+		t.Errorf("Expected no flag error function, got %v", err)
+	}
+
+	// Changes in cobra fail loudly
+	if _, err := commandField(cmd, "renamed", contextType); err == nil {
+		t.Error("Expected an error for a missing field")
+	}
+	if _, err := commandField(cmd, "ctx", reflect.TypeOf("")); err == nil {
+		t.Error("Expected an error for a field of another type")
+	}
+}
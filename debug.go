@@ -0,0 +1,65 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// DebugEnv is the environment variable that enables debug logging to stderr when set to a true value (e.g. "1")
+const DebugEnv = "COBRAHOOKS_DEBUG"
+
+var debugOut io.Writer
+
+// SetDebugWriter enables logging of the hook dispatching to w:
+// which hooks are considered for a command, which are skipped and why, and
+// which are executed, together with where they were registered.
+// Pass nil to disable the logging again.
+func SetDebugWriter(w io.Writer) {
+	debugOut = w
+}
+
+// debugWriter returns the writer to log to or nil when debug logging is disabled
+func debugWriter() io.Writer {
+	if debugOut != nil {
+		return debugOut
+	}
+	if isTrue(os.Getenv(DebugEnv)) {
+		return os.Stderr
+	}
+	return nil
+}
+
+func isTrue(s string) bool {
+	switch s {
+	case "1", "t", "true", "TRUE", "True":
+		return true
+	}
+	return false
+}
+
+func debugf(w io.Writer, format string, a ...interface{}) {
+	fmt.Fprintf(w, "cobrahooks: "+format+"\n", a...)
+}
+
+// describe returns the hook's function, registration site and command for logging
+func (ch *commandHook) describe() string {
+	return fmt.Sprintf("%s (%s:%d) on %q", ch.displayName(), filepath.Base(ch.file), ch.line, ch.cmd.CommandPath())
+}
+
+// debugCandidates logs the hooks considered for the command and why they are skipped
+func debugCandidates(w io.Writer, cmd *cobra.Command, p Phase, mode runMode) {
+	debugf(w, "%s hooks for %q (%s)", p, cmd.CommandPath(), mode)
+	forEachCandidate(cmd, p, func(ch *commandHook) {
+		if reason := ch.skipReason(cmd, mode); reason != "" {
+			debugf(w, "  skip %s: %s", ch.describe(), reason)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestDebugWriter(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Run: emptyRun}}
+	childCmd := &Command{&cobra.Command{Use: "child", Run: emptyRun}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	rootCmd.OnPersistentPreRun(noopHook, RunOnHelp)
+	childCmd.OnPreRun(noopHook)
+	rootCmd.OnHelp(noopHook)
+	childCmd.OnRun(func(_ *cobra.Command, _ []string) error {
+		return errors.New("failed")
+	})
+
+	buf := new(bytes.Buffer)
+	SetDebugWriter(buf)
+	defer SetDebugWriter(nil)
+
+	if _, err := executeCommand(rootCmd.Command, "child"); err == nil {
+		t.Errorf("Expected error")
+	}
+	log := buf.String()
+	checkStringContains(t, log, "cobrahooks: PersistentPreRun hooks for \"root child\" (run)\n"+
+		"cobrahooks:   run  github.com/bartdeboer/cobrahooks.noopHook (debug_test.go:16) on \"root\"\n")
+	checkStringContains(t, log, "cobrahooks: Run hooks for \"root child\" (run)\n"+
+		"cobrahooks:   run  github.com/bartdeboer/cobrahooks.TestDebugWriter.func1 (debug_test.go:19) on \"root child\"\n"+
+		"cobrahooks:   fail github.com/bartdeboer/cobrahooks.TestDebugWriter.func1 (debug_test.go:19) on \"root child\": failed\n")
+
+	buf.Reset()
+	if _, err := executeCommand(rootCmd.Command, "child", "--help"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	log = buf.String()
+	checkStringContains(t, log, "cobrahooks: PreRun hooks for \"root child\" (help)\n"+
+		"cobrahooks:   skip github.com/bartdeboer/cobrahooks.noopHook (debug_test.go:17) on \"root child\": not registered with RunOnHelp\n")
+	checkStringContains(t, log, "cobrahooks: Help hooks for \"root child\" (help)\n"+
+		"cobrahooks:   skip github.com/bartdeboer/cobrahooks.noopHook (debug_test.go:18) on \"root\": not persistent\n")
+}
@@ -0,0 +1,116 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type commandState struct {
+	short      string
+	long       string
+	example    string
+	deprecated string
+	version    string
+	hidden     bool
+}
+
+type flagState struct {
+	defValue    string
+	noOptDefVal string
+	usage       string
+	deprecated  string
+	hidden      bool
+}
+
+// PrepareForDocs runs the hooks that would run when help is invoked (the
+// PersistentPreRun and PreRun hooks registered with RunOnHelp and the OnHelp
+// hooks) for every command in the tree, and calls gen for the command right
+// after, so documentation generated with cobra's doc package matches the
+// output of --help:
+//
+//	err := cobrahooks.PrepareForDocs(rootCmd, func(cmd *cobra.Command) error {
+//	    f, err := os.Create(filepath.Join("docs", strings.Replace(cmd.CommandPath(), " ", "_", -1)+".md"))
+//	    if err != nil {
+//	        return err
+//	    }
+//	    defer f.Close()
+//	    return doc.GenMarkdown(cmd, f)
+//	})
+//
+// The commands and flags are reverted to their previous state after every
+// command, as persistent flags are shared with the child commands.
+func PrepareForDocs(root *cobra.Command, gen func(cmd *cobra.Command) error) error {
+	var cmds []*cobra.Command
+	walkCommands(root, func(c *cobra.Command) {
+		c.InitDefaultHelpFlag()
+		// Merge the persistent flags of the parents so hooks can look them up
+		c.InheritedFlags()
+		cmds = append(cmds, c)
+	})
+
+	commands := make(map[*cobra.Command]commandState)
+	flags := make(map[*pflag.Flag]flagState)
+	for _, c := range cmds {
+		commands[c] = commandState{
+			short:      c.Short,
+			long:       c.Long,
+			example:    c.Example,
+			deprecated: c.Deprecated,
+			version:    c.Version,
+			hidden:     c.Hidden,
+		}
+		saveFlag := func(f *pflag.Flag) {
+			flags[f] = flagState{
+				defValue:    f.DefValue,
+				noOptDefVal: f.NoOptDefVal,
+				usage:       f.Usage,
+				deprecated:  f.Deprecated,
+				hidden:      f.Hidden,
+			}
+		}
+		c.Flags().VisitAll(saveFlag)
+		c.PersistentFlags().VisitAll(saveFlag)
+	}
+
+	restore := func() {
+		for c, s := range commands {
+			c.Short = s.short
+			c.Long = s.long
+			c.Example = s.example
+			c.Deprecated = s.deprecated
+			c.Version = s.version
+			c.Hidden = s.hidden
+		}
+		for f, s := range flags {
+			f.DefValue = s.defValue
+			f.NoOptDefVal = s.noOptDefVal
+			f.Usage = s.usage
+			f.Deprecated = s.deprecated
+			f.Hidden = s.hidden
+		}
+	}
+	defer restore()
+
+	for _, c := range cmds {
+		if err := runHelpHooks(c, []string{}); err != nil {
+			return err
+		}
+		if err := gen(c); err != nil {
+			return err
+		}
+		restore()
+	}
+	return nil
+}
+
+// walkCommands calls fn for the command and all of its childs, parents first
+func walkCommands(c *cobra.Command, fn func(*cobra.Command)) {
+	fn(c)
+	for _, child := range c.Commands() {
+		walkCommands(child, fn)
+	}
+}
@@ -0,0 +1,114 @@
+package cobrahooks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestPrepareForDocs(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Run: emptyRun}}
+	childCmd := &Command{&cobra.Command{Use: "child", Short: "Child", Run: emptyRun}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	var rootFlagValue, childFlagValue, unchangedFlagValue string
+	rootCmd.PersistentFlags().StringVarP(&rootFlagValue, "root-flag", "", "", "")
+	childCmd.Flags().StringVarP(&childFlagValue, "child-flag", "", "", "")
+	childCmd.Flags().StringVarP(&unchangedFlagValue, "unchanged-flag", "", "", "")
+
+	rootCmd.OnPersistentPreRun(func(cmd *cobra.Command, args []string) error {
+		cmd.Flags().Lookup("root-flag").DefValue = "root docs value"
+		return nil
+	}, RunOnHelp)
+	childCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		cmd.Flags().Lookup("child-flag").DefValue = "child docs value"
+		return nil
+	}, RunOnHelp)
+	childCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		cmd.Flags().Lookup("unchanged-flag").DefValue = "should not be set"
+		return nil
+	})
+	childCmd.OnHelp(func(cmd *cobra.Command, args []string) error {
+		cmd.Short = "Child with docs"
+		return nil
+	})
+
+	var usage, short string
+	err := PrepareForDocs(rootCmd.Command, func(cmd *cobra.Command) error {
+		if cmd == childCmd.Command {
+			usage, short = cmd.UsageString(), cmd.Short
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	checkStringContains(t, usage, "(default \"root docs value\")")
+	checkStringContains(t, usage, "(default \"child docs value\")")
+	checkStringOmits(t, usage, "should not be set")
+	if short != "Child with docs" {
+		t.Errorf("Expected Short %q, got %q", "Child with docs", short)
+	}
+
+	usage = childCmd.UsageString()
+	checkStringOmits(t, usage, "docs value")
+	if childCmd.Short != "Child" {
+		t.Errorf("Expected Short %q, got %q", "Child", childCmd.Short)
+	}
+}
+
+func TestPrepareForDocsError(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Short: "Root", Run: emptyRun}}
+	rootCmd.OnHelp(func(cmd *cobra.Command, args []string) error {
+		cmd.Short = "Changed"
+		return nil
+	})
+	rootCmd.OnHelp(func(cmd *cobra.Command, args []string) error {
+		return errors.New("help hook failed")
+	})
+
+	err := PrepareForDocs(rootCmd.Command, func(cmd *cobra.Command) error {
+		t.Errorf("Expected no documentation to be generated for %q", cmd.Name())
+		return nil
+	})
+	if err == nil || err.Error() != "help hook failed" {
+		t.Errorf("Expected error %q, got %v", "help hook failed", err)
+	}
+	if rootCmd.Short != "Root" {
+		t.Errorf("Expected Short %q, got %q", "Root", rootCmd.Short)
+	}
+}
+
+func TestPrepareForDocsPerCommand(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Run: emptyRun}}
+	childCmd := &Command{&cobra.Command{Use: "child", Run: emptyRun}}
+	grandChildCmd := &Command{&cobra.Command{Use: "grandchild", Run: emptyRun}}
+	rootCmd.AddCommand(childCmd.Command)
+	childCmd.AddCommand(grandChildCmd.Command)
+	childCmd.PersistentFlags().String("child-flag", "", "")
+
+	childCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		cmd.Flags().Lookup("child-flag").DefValue = "child new default value overwrite"
+		return nil
+	}, RunOnHelp, Persistent)
+	childCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		cmd.Flags().Lookup("child-flag").DefValue = "child new default value should not overwrite"
+		return nil
+	}, RunOnHelp)
+
+	usages := make(map[string]string)
+	err := PrepareForDocs(rootCmd.Command, func(cmd *cobra.Command) error {
+		usages[cmd.Name()] = cmd.UsageString()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The flag is shared by the child and the grandchild
+	checkStringContains(t, usages["child"], "(default \"child new default value should not overwrite\")")
+	checkStringContains(t, usages["grandchild"], "(default \"child new default value overwrite\")")
+	checkStringOmits(t, childCmd.UsageString(), "default")
+}
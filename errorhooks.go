@@ -0,0 +1,90 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// OnError registers an Error hook on the command and all of its childs.
+func (c *Command) OnError(h func(cmd *cobra.Command, p Phase, err error) error, options ...func(*HookOptions)) {
+	OnError(c.Command, h, options...)
+}
+
+// OnError registers an Error hook on the command and all of its childs.
+//
+// The Error hooks run when the hooks of a phase fail, before cobra prints
+// the error, and receive the command, the phase and the error. They return
+// the error to fail with, which allows them to add hints, translate or wrap
+// it, or to return nil to recover from it. They can also call SilenceUsage
+// and SilenceErrors to decide what cobra prints for the execution:
+//
+//	cobrahooks.OnError(rootCmd, func(cmd *cobra.Command, p cobrahooks.Phase, err error) error {
+//	    if errors.Is(err, ErrNotLoggedIn) {
+//	        cobrahooks.SilenceUsage(cmd)
+//	        return fmt.Errorf("%w (run %q first)", err, "myapp login")
+//	    }
+//	    return err
+//	})
+//
+// Every Error hook receives the error returned by the previous one.
+func OnError(c *cobra.Command, h func(cmd *cobra.Command, p Phase, err error) error, options ...func(*HookOptions)) {
+	p, opts := hookOptions(ErrorPhase, options)
+	ch := newCommandHook(c, p, nil, opts)
+	ch.onError = h
+	// Register the hook
+	register(ch)
+}
+
+// runErrorHooks runs the Error hooks of the command for the error of the phase
+func runErrorHooks(cmd *cobra.Command, p Phase, err error) error {
+	debug := debugWriter()
+	for _, ch := range registered.chain(cmd, ErrorPhase, normalRun) {
+		if err == nil {
+			break
+		}
+		if ch.disabledReason(cmd) != "" {
+			continue
+		}
+		if debug != nil {
+			debugf(debug, "  error %s: %v", ch.describe(), err)
+		}
+		err = ch.onError(cmd, p, err)
+	}
+	return err
+}
+
+// JSONErrors registers an Error hook on the root command that prints the
+// errors as JSON, instead of cobra printing them with the usage, when the
+// flag with the name is set to "json" (e.g. --output=json):
+//
+//	{"error":"...","command":"myapp get","phase":"Run"}
+//
+// Register it after the Error hooks that transform the errors. The command's
+// SilenceErrors and SilenceUsage fields are restored after the execution.
+// When the JSON fails to print, cobra prints the error as usual, with the
+// reason added.
+func JSONErrors(root *cobra.Command, flag string) {
+	OnError(root, func(cmd *cobra.Command, p Phase, err error) error {
+		f := cmd.Flags().Lookup(flag)
+		if f == nil || f.Value.String() != "json" {
+			return err
+		}
+		if jerr := json.NewEncoder(cmd.ErrOrStderr()).Encode(struct {
+			Error   string `json:"error"`
+			Command string `json:"command"`
+			Phase   Phase  `json:"phase"`
+		}{err.Error(), cmd.CommandPath(), p}); jerr != nil {
+			// Leave it to cobra to print the error
+			return fmt.Errorf("%w (printing the error as JSON: %v)", err, jerr)
+		}
+		SilenceErrors(cmd)
+		SilenceUsage(cmd)
+		return err
+	}, Name("json-errors"))
+}
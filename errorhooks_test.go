@@ -0,0 +1,112 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestOnError(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	childCmd := &Command{&cobra.Command{Use: "child"}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	errNotLoggedIn := errors.New("not logged in")
+	var (
+		runErr error
+		phases []Phase
+	)
+	childCmd.OnPreRun(func(_ *cobra.Command, _ []string) error { return nil })
+	childCmd.OnRun(func(_ *cobra.Command, _ []string) error { return runErr })
+	rootCmd.OnError(func(cmd *cobra.Command, p Phase, err error) error {
+		phases = append(phases, p)
+		if errors.Is(err, errNotLoggedIn) {
+			SilenceUsage(cmd)
+			return fmt.Errorf("%w (run \"root login\" first)", err)
+		}
+		return err
+	})
+	rootCmd.OnError(func(cmd *cobra.Command, p Phase, err error) error {
+		if err.Error() == "ignore me" {
+			return nil
+		}
+		return err
+	})
+
+	runErr = errNotLoggedIn
+	output, err := executeCommand(rootCmd.Command, "child")
+	if !errors.Is(err, errNotLoggedIn) {
+		t.Errorf("Expected the wrapped error, got %v", err)
+	}
+	checkStringContains(t, output, `Error: not logged in (run "root login" first)`)
+	checkStringOmits(t, output, "Usage:")
+	if len(phases) != 1 || phases[0] != RunPhase {
+		t.Errorf("Expected the Error hooks to run once for the Run phase, got %v", phases)
+	}
+
+	runErr = errors.New("ignore me")
+	if _, err := executeCommand(rootCmd.Command, "child"); err != nil {
+		t.Errorf("Expected the Error hook to recover from the error, got %v", err)
+	}
+	if childCmd.SilenceUsage {
+		t.Error("Expected SilenceUsage to be restored for the next execution")
+	}
+
+	runErr = nil
+	phases = nil
+	if _, err := executeCommand(rootCmd.Command, "child"); err != nil || len(phases) != 0 {
+		t.Errorf("Expected the Error hooks not to run, got %v, %v", phases, err)
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	getCmd := &cobra.Command{Use: "get"}
+	rootCmd.AddCommand(getCmd)
+	rootCmd.PersistentFlags().String("output", "text", "")
+	OnRun(getCmd, func(_ *cobra.Command, _ []string) error {
+		return errors.New("connection refused")
+	})
+	JSONErrors(rootCmd, "output")
+
+	output, err := executeCommand(rootCmd, "get", "--output=json")
+	if err == nil {
+		t.Errorf("Expected an error")
+	}
+	if output != "{\"error\":\"connection refused\",\"command\":\"root get\",\"phase\":\"Run\"}\n" {
+		t.Errorf("Expected the error as JSON only, got %q", output)
+	}
+
+	// Flag values persist between executions
+	output, _ = executeCommand(rootCmd, "get", "--output=text")
+	checkStringContains(t, output, "Error: connection refused")
+
+	// The fields are restored after executions through Execute
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	SetArgs(rootCmd, []string{"get", "--output=json"})
+	Execute(rootCmd)
+	if getCmd.SilenceErrors || getCmd.SilenceUsage {
+		t.Error("Expected SilenceErrors and SilenceUsage to be restored")
+	}
+
+	// Cobra prints the error when the JSON fails to print
+	buf.Reset()
+	rootCmd.SetErr(failingWriter{})
+	err = Execute(rootCmd)
+	if err == nil || !strings.Contains(err.Error(), "printing the error as JSON: write failed") {
+		t.Errorf("Expected the error to report the failure, got %v", err)
+	}
+	checkStringContains(t, buf.String(), "Error: connection refused (printing the error as JSON: write failed)")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
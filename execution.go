@@ -0,0 +1,68 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"errors"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// ErrStopHooks can be returned by a hook to stop running the remaining hooks
+// of its phase without failing the command.
+var ErrStopHooks = errors.New("cobrahooks: stop hooks")
+
+// ErrSkipRun can be returned by a PersistentPreRun or PreRun hook to skip the
+// remaining PersistentPreRun and PreRun hooks and the Run hooks without
+// failing the command (e.g. when a cached result means there is nothing left
+// to do). The PostRun and PersistentPostRun hooks still run.
+// Note that a user-defined Run or RunE field of the command still runs.
+var ErrSkipRun = errors.New("cobrahooks: skip run")
+
+// execution holds the state of the execution of a command
+type execution struct {
+	cmd     *cobra.Command
+	skipRun bool
+	// args holds the arguments as rewritten by the hooks
+	args          []string
+	argsRewritten bool
+	// redirected is set when a hook redirected the execution to another command
+	redirected bool
+	redirects  int
+	// phase and mode are those of the hooks being run
+	phase Phase
+	mode  runMode
+	start time.Time
+	store map[string]interface{}
+	// err is the error the execution failed with
+	err error
+	// explained is set when the hooks were explained instead of run
+	explained bool
+}
+
+var current *execution
+
+// currentExecution returns the state of the command's current execution
+func currentExecution(cmd *cobra.Command) *execution {
+	if current == nil || current.cmd != cmd {
+		current = newExecution(cmd)
+	}
+	return current
+}
+
+func newExecution(cmd *cobra.Command) *execution {
+	return &execution{
+		cmd:   cmd,
+		start: time.Now(),
+		store: make(map[string]interface{}),
+	}
+}
+
+// resetExecution is called by cobra when a command starts executing
+func resetExecution() {
+	current = nil
+	restoreUsage()
+}
@@ -0,0 +1,76 @@
+package cobrahooks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestShortCircuitErrors(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	childCmd := &Command{&cobra.Command{Use: "child"}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	var (
+		ran     []string
+		cached  bool
+		stopped bool
+	)
+	hook := func(name string, err func() error) func(*cobra.Command, []string) error {
+		return func(_ *cobra.Command, _ []string) error {
+			ran = append(ran, name)
+			return err()
+		}
+	}
+	noErr := func() error { return nil }
+
+	rootCmd.OnPersistentPreRun(hook("cache", func() error {
+		if cached {
+			return ErrSkipRun
+		}
+		return nil
+	}), Name("cache"))
+	rootCmd.OnPersistentPreRun(hook("persPre", noErr))
+	childCmd.OnPreRun(hook("pre", noErr))
+	childCmd.OnRun(hook("run1", func() error {
+		if stopped {
+			return ErrStopHooks
+		}
+		return nil
+	}))
+	childCmd.OnRun(hook("run2", noErr))
+	childCmd.OnPostRun(hook("post", noErr))
+	rootCmd.OnPersistentPostRun(hook("persPost", noErr))
+
+	run := func() string {
+		ran = nil
+		output, err := executeCommand(rootCmd.Command, "child")
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		if output != "" {
+			t.Errorf("Unexpected output: %v", output)
+		}
+		return strings.Join(ran, " ")
+	}
+
+	if got := run(); got != "cache persPre pre run1 run2 post persPost" {
+		t.Errorf("Unexpected hooks: %q", got)
+	}
+
+	stopped = true
+	if got := run(); got != "cache persPre pre run1 post persPost" {
+		t.Errorf("Expected ErrStopHooks to stop the Run hooks, got %q", got)
+	}
+
+	cached = true
+	if got := run(); got != "cache post persPost" {
+		t.Errorf("Expected ErrSkipRun to skip to the PostRun hooks, got %q", got)
+	}
+
+	cached, stopped = false, false
+	if got := run(); got != "cache persPre pre run1 run2 post persPost" {
+		t.Errorf("Expected ErrSkipRun to only affect one execution, got %q", got)
+	}
+}
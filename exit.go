@@ -0,0 +1,107 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/spf13/cobra"
+)
+
+// ExitError is an error that exits the process with a specific code when
+// returned from the execution of ExecuteAndExit.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+type exitCodeMapping struct {
+	target error
+	typ    reflect.Type
+	code   int
+}
+
+var exitCodes []exitCodeMapping
+
+// RegisterExitCode maps the errors that match the target (see errors.Is) to the exit code.
+func RegisterExitCode(target error, code int) {
+	exitCodes = append(exitCodes, exitCodeMapping{target: target, code: code})
+}
+
+// RegisterExitCodeType maps the errors of the same type as the example to
+// the exit code, also when they are wrapped:
+//
+//	cobrahooks.RegisterExitCodeType(&net.OpError{}, 69)
+func RegisterExitCodeType(example error, code int) {
+	exitCodes = append(exitCodes, exitCodeMapping{typ: reflect.TypeOf(example), code: code})
+}
+
+// ExitCode returns the exit code for the error: 0 for nil, the code of an
+// ExitError, the code registered for the error or else 1. Errors registered
+// last take precedence.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	for i := len(exitCodes) - 1; i >= 0; i-- {
+		m := exitCodes[i]
+		if m.target != nil && errors.Is(err, m.target) {
+			return m.code
+		}
+		if m.typ != nil {
+			for e := err; e != nil; e = errors.Unwrap(e) {
+				if reflect.TypeOf(e) == m.typ {
+					return m.code
+				}
+			}
+		}
+	}
+	return 1
+}
+
+var exitFunc = os.Exit
+
+// SetExitFunc replaces the function ExecuteAndExit exits the process with,
+// for tests. Pass nil to restore os.Exit.
+func SetExitFunc(exit func(code int)) {
+	if exit == nil {
+		exit = os.Exit
+	}
+	exitFunc = exit
+}
+
+// ExecuteAndExit executes the root command through ExecuteC, prints the
+// error unless cobra already did or the errors are silenced, and exits the
+// process with the exit code of the error (see ExitCode).
+func ExecuteAndExit(root *cobra.Command) {
+	cmd, printed, err := executeC(root)
+	if err != nil && !printed {
+		if cmd == nil {
+			cmd = root
+		}
+		if !cmd.SilenceErrors && !root.SilenceErrors {
+			cmd.PrintErrln("Error:", err.Error())
+		}
+	}
+	exitFunc(ExitCode(err))
+}
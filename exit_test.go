@@ -0,0 +1,85 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type quotaError struct{ limit int }
+
+func (e *quotaError) Error() string { return fmt.Sprintf("quota of %d exceeded", e.limit) }
+
+func TestExitCode(t *testing.T) {
+	errNotFound := errors.New("not found")
+	RegisterExitCode(errNotFound, 4)
+	RegisterExitCodeType(&quotaError{}, 5)
+
+	for _, c := range []struct {
+		err  error
+		code int
+	}{
+		{nil, 0},
+		{errors.New("failed"), 1},
+		{&ExitError{Code: 3, Err: errNotFound}, 3},
+		{fmt.Errorf("get: %w", errNotFound), 4},
+		{fmt.Errorf("upload: %w", &quotaError{10}), 5},
+	} {
+		if code := ExitCode(c.err); code != c.code {
+			t.Errorf("Expected exit code %d for %v, got %d", c.code, c.err, code)
+		}
+	}
+}
+
+func TestExecuteAndExit(t *testing.T) {
+	var code int
+	SetExitFunc(func(c int) { code = c })
+	defer SetExitFunc(nil)
+
+	rootCmd := &cobra.Command{Use: "root"}
+	childCmd := &cobra.Command{Use: "child"}
+	rootCmd.AddCommand(childCmd)
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(out)
+
+	var runErr, finallyErr error
+	OnRun(childCmd, func(_ *cobra.Command, _ []string) error { return runErr })
+	OnFinally(rootCmd, func(_ *cobra.Command, _ []string) error { return finallyErr })
+
+	run := func(args ...string) string {
+		out.Reset()
+		code = -1
+		SetArgs(rootCmd, args)
+		ExecuteAndExit(rootCmd)
+		return out.String()
+	}
+
+	if output := run("child"); code != 0 || output != "" {
+		t.Errorf("Expected exit code 0 without output, got %d, %q", code, output)
+	}
+
+	runErr = &ExitError{Code: 7, Err: errors.New("unavailable")}
+	output := run("child")
+	if code != 7 {
+		t.Errorf("Expected exit code 7, got %d", code)
+	}
+	// Printed by cobra only
+	if n := bytes.Count([]byte(output), []byte("Error: unavailable")); n != 1 {
+		t.Errorf("Expected the error to be printed once, got %q", output)
+	}
+
+	// Cobra does not print the errors of the Finally hooks
+	runErr, finallyErr = nil, errors.New("cleanup failed")
+	if output := run("child"); code != 1 || output != "Error: cleanup failed\n" {
+		t.Errorf("Expected the error of the Finally hook to be printed, got %d, %q", code, output)
+	}
+
+	rootCmd.SilenceErrors = true
+	if output := run("child"); code != 1 || output != "" {
+		t.Errorf("Expected the silenced error not to be printed, got %d, %q", code, output)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// ExplainEnv is the environment variable that enables the explain mode when set to a true value (e.g. "1")
+const ExplainEnv = "COBRAHOOKS_EXPLAIN"
+
+var explaining bool
+
+// SetExplain enables or disables the explain mode.
+//
+// In explain mode, executing a command prints the hooks that would run for
+// it, in order, instead of running them. The user-defined
+// (Persistent)(Pre/Post)Run(E) fields of the commands with hooks and of the
+// other commands in their trees do not run either, and the execution
+// succeeds.
+func SetExplain(enabled bool) {
+	explaining = enabled
+}
+
+func isExplaining() bool {
+	return explaining || isTrue(os.Getenv(ExplainEnv))
+}
+
+// dispatchedBy reports whether cobra calls the dispatcher of this package for the phase when executing the command
+func dispatchedBy(cmd *cobra.Command, p Phase) bool {
+	if p == PersistentPreRunPhase || p == PersistentPostRunPhase {
+		// Cobra only calls the field of the nearest command that defines it
+		for c := cmd; c != nil; c = c.Parent() {
+			if hasE, hasPlain := explainedFields(c, p); hasE || hasPlain {
+				return isDispatcher(c, p)
+			}
+		}
+		return false
+	}
+	return isDispatcher(cmd, p)
+}
+
+// explainedFields is lifecycleFields for the fields before they were replaced for the explain mode
+func explainedFields(c *cobra.Command, p Phase) (hasE bool, hasPlain bool) {
+	hasE, hasPlain = lifecycleFields(c, p)
+	if f, ok := explainFields[dispatcher{c, p}]; ok {
+		hasE = f != nil
+	}
+	return hasE, hasPlain
+}
+
+// explainFields holds the user-defined fields replaced while the explain mode is on
+var explainFields = make(map[dispatcher]func(*cobra.Command, []string) error)
+
+// initExplain is called by Execute before cobra checks whether the command
+// is runnable, and by cobra when a command starts executing. While the
+// explain mode is on, it sets the user-defined fields of the commands in the
+// trees with hooks to explain the hooks instead of running them, and restores
+// them afterwards. Only the fields that are set are replaced, so the commands
+// stay runnable or not.
+func initExplain() {
+	if !isExplaining() {
+		for d, f := range explainFields {
+			*lifecycleField(d.cmd, d.phase) = f
+			delete(explainFields, d)
+		}
+		return
+	}
+	for _, root := range hookedRoots() {
+		walkCommands(root, func(c *cobra.Command) {
+			for p := PersistentPreRunPhase; p <= PersistentPostRunPhase; p++ {
+				p := p
+				d := dispatcher{c, p}
+				if _, ok := explainFields[d]; ok || isDispatcher(c, p) {
+					continue
+				}
+				if hasE, hasPlain := lifecycleFields(c, p); !hasE && !hasPlain {
+					continue
+				}
+				field := lifecycleField(c, p)
+				explainFields[d] = *field
+				*field = func(cmd *cobra.Command, args []string) error {
+					return dispatch(cmd, args, p)
+				}
+			}
+		})
+	}
+}
+
+// hookedRoots returns the root commands of the commands with hooks
+func hookedRoots() []*cobra.Command {
+	var roots []*cobra.Command
+	seen := make(map[*cobra.Command]bool)
+	for c := range registered.commands {
+		if r := c.Root(); !seen[r] {
+			seen[r] = true
+			roots = append(roots, r)
+		}
+	}
+	return roots
+}
+
+// lifecycleField returns the command's (Persistent)(Pre/Post)RunE field for the phase
+func lifecycleField(c *cobra.Command, p Phase) *func(*cobra.Command, []string) error {
+	switch p {
+	case PersistentPreRunPhase:
+		return &c.PersistentPreRunE
+	case PreRunPhase:
+		return &c.PreRunE
+	case RunPhase:
+		return &c.RunE
+	case PostRunPhase:
+		return &c.PostRunE
+	}
+	return &c.PersistentPostRunE
+}
+
+// explain prints the hooks that would run for the command
+func explain(cmd *cobra.Command) {
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "Hooks for %q:\n", cmd.CommandPath())
+	for p := PersistentPreRunPhase; p <= PersistentPostRunPhase; p++ {
+		if !dispatchedBy(cmd, p) {
+			continue
+		}
+		for _, ch := range hookChain(cmd, p, normalRun) {
+			fmt.Fprintf(w, "  %-18s [%s] %s (%s:%d)\n", p, ch.cmd.CommandPath(), ch.displayName(), filepath.Base(ch.file), ch.line)
+		}
+	}
+	for _, ch := range hookChain(cmd, FinallyPhase, normalRun) {
+		fmt.Fprintf(w, "  %-18s [%s] %s (%s:%d)\n", FinallyPhase, ch.cmd.CommandPath(), ch.displayName(), filepath.Base(ch.file), ch.line)
+	}
+}
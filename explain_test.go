@@ -0,0 +1,125 @@
+package cobrahooks
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestExplain(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Run: emptyRun}}
+	childCmd := &Command{&cobra.Command{Use: "child"}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	var ran, ranUserRun bool
+	// A command without hooks
+	deleteCmd := &cobra.Command{Use: "delete", Run: func(_ *cobra.Command, _ []string) { ranUserRun = true }}
+	rootCmd.AddCommand(deleteCmd)
+	hook := func(_ *cobra.Command, _ []string) error {
+		ran = true
+		return nil
+	}
+	rootCmd.OnPersistentPreRun(hook)
+	childCmd.OnPreRun(hook)
+	childCmd.OnRun(hook)
+	rootCmd.OnRun(hook)
+	rootCmd.OnPersistentPostRun(hook)
+
+	SetExplain(true)
+	output, err := executeCommand(rootCmd.Command, "child")
+	SetExplain(false)
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if ran {
+		t.Errorf("Expected no hooks to run")
+	}
+	expected := "Hooks for \"root child\":\n" +
+		"  PersistentPreRun   [root] github.com/bartdeboer/cobrahooks.TestExplain.func2 (explain_test.go:23)\n" +
+		"  PreRun             [root child] github.com/bartdeboer/cobrahooks.TestExplain.func2 (explain_test.go:24)\n" +
+		"  Run                [root child] github.com/bartdeboer/cobrahooks.TestExplain.func2 (explain_test.go:25)\n" +
+		"  PersistentPostRun  [root] github.com/bartdeboer/cobrahooks.TestExplain.func2 (explain_test.go:27)\n"
+	if output != expected {
+		t.Errorf("Expected:\n%v\nGot:\n%v", expected, output)
+	}
+
+	os.Setenv(ExplainEnv, "1")
+	output, err = executeCommand(rootCmd.Command)
+	os.Unsetenv(ExplainEnv)
+
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Hooks for \"root\":\n")
+	if ran {
+		t.Errorf("Expected no hooks to run")
+	}
+
+	SetExplain(true)
+	output, err = executeCommand(rootCmd.Command, "delete")
+	SetExplain(false)
+
+	if err != nil || ranUserRun {
+		t.Errorf("Expected the user-defined Run not to run, got %v, %v", ranUserRun, err)
+	}
+	expected = "Hooks for \"root delete\":\n" +
+		"  PersistentPreRun   [root] github.com/bartdeboer/cobrahooks.TestExplain.func2 (explain_test.go:23)\n" +
+		"  PersistentPostRun  [root] github.com/bartdeboer/cobrahooks.TestExplain.func2 (explain_test.go:27)\n"
+	if output != expected {
+		t.Errorf("Expected:\n%v\nGot:\n%v", expected, output)
+	}
+	if _, err := executeCommand(rootCmd.Command, "delete"); err != nil || !ranUserRun {
+		t.Errorf("Expected the user-defined Run to be restored, got %v, %v", ranUserRun, err)
+	}
+
+	output, err = executeCommand(rootCmd.Command, "child")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if !ran {
+		t.Errorf("Expected the hooks to run")
+	}
+}
+
+func TestExplainGroupCommand(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	childCmd := &cobra.Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.OnPersistentPreRun(func(_ *cobra.Command, _ []string) error { return nil })
+
+	// The root command has no Run and shows the help
+	SetExplain(true)
+	output, err := executeCommand(rootCmd.Command)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Available Commands:")
+	checkStringOmits(t, output, "Hooks for")
+
+	output, err = executeCommand(rootCmd.Command, "child")
+	SetExplain(false)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "Hooks for \"root child\":\n")
+
+	for _, execute := range []func() (string, error){
+		func() (string, error) { return executeCommand(rootCmd.Command) },
+		func() (string, error) {
+			SetArgs(rootCmd.Command, nil)
+			err := Execute(rootCmd.Command)
+			return "", err
+		},
+	} {
+		output, err := execute()
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		checkStringOmits(t, output, "Hooks for")
+		if rootCmd.RunE != nil {
+			t.Error("Expected the root command to stay not runnable")
+		}
+	}
+}
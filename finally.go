@@ -0,0 +1,138 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// OnFinally registers a Finally hook on the command and all of its childs.
+func (c *Command) OnFinally(h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	OnFinally(c.Command, h, options...)
+}
+
+// OnFinally registers a Finally hook on the command and all of its childs.
+//
+// The Finally hooks run at the end of every execution through Execute or
+// ExecuteC, whether it succeeded, failed (e.g. because of an invalid flag or
+// a failing hook) or showed the help or the version. Use Invoke to get the
+// error the execution failed with:
+//
+//	cobrahooks.OnFinally(rootCmd, cobrahooks.Invoke(func(inv *cobrahooks.Invocation) error {
+//	    log.Printf("%s: %v", inv.Command.CommandPath(), inv.Err)
+//	    return nil
+//	}))
+func OnFinally(c *cobra.Command, h func(cmd *cobra.Command, args []string) error, options ...func(*HookOptions)) {
+	On(c, FinallyPhase, h, options...)
+}
+
+// Execute executes the root command like its Execute method does, followed
+// by the Finally hooks.
+func Execute(root *cobra.Command) error {
+	_, err := ExecuteC(root)
+	return err
+}
+
+// ExecuteC executes the root command like its ExecuteC method does, followed
+// by the Finally hooks. The error of the execution takes precedence over the
+// errors of the Finally hooks.
+func ExecuteC(root *cobra.Command) (*cobra.Command, error) {
+	cmd, _, err := executeC(root)
+	return cmd, err
+}
+
+// executeC executes the root command followed by the Finally hooks, and
+// reports whether cobra printed the error
+func executeC(root *cobra.Command) (cmd *cobra.Command, printed bool, err error) {
+	start := time.Now()
+	executing[root] = true
+	defer delete(executing, root)
+	// inv describes the finished execution, it is nil when the execution panicked
+	var inv *Invocation
+	for _, wrap := range executeWrappers[root] {
+		done := wrap()
+		defer func() {
+			done(inv)
+		}()
+	}
+	// The initializers do not run when the flags fail to parse
+	resetExecution()
+	initExplain()
+	initialized = false
+	defer restoreUsageErrors(root)
+	if err := classifyUsageErrors(root); err != nil {
+		return nil, false, err
+	}
+	h := signalHandlers[root]
+	if h == nil {
+		cmd, err = root.ExecuteC()
+	} else {
+		h.start()
+		cmd, err = h.executeRoot(root)
+	}
+	err = classifyCommandError(cmd, err)
+	restoreUsage()
+	printed = err != nil && cmd != nil && !cmd.SilenceErrors && !root.SilenceErrors
+	if h != nil {
+		h.teardown(cmd, err)
+	}
+	err = runFinallyHooks(cmd, start, err)
+	if h != nil {
+		err = h.stop(err)
+	}
+	if cmd != nil {
+		inv = newInvocation(cmd, executionArgs(cmd))
+		inv.Start, inv.Err = start, err
+	}
+	return cmd, printed, err
+}
+
+// executing holds the root commands being executed through executeC
+var executing = make(map[*cobra.Command]bool)
+
+// executeWrappers wrap the executions of root commands through Execute,
+// ExecuteC and ExecuteAndExit. They are called before the execution and
+// return the function to call after it, with the finished execution.
+var executeWrappers = make(map[*cobra.Command][]func() func(inv *Invocation))
+
+// executionArgs returns the arguments of the command's execution, as rewritten by the hooks
+func executionArgs(cmd *cobra.Command) []string {
+	if e := currentExecution(cmd); e.argsRewritten {
+		return e.args
+	}
+	return cmd.Flags().Args()
+}
+
+// runFinallyHooks runs the Finally hooks of the executed command and returns the error of the execution
+func runFinallyHooks(cmd *cobra.Command, start time.Time, err error) error {
+	if cmd == nil {
+		return err
+	}
+	e := currentExecution(cmd)
+	if e.explained {
+		return err
+	}
+	e.start, e.err = start, err
+	args := executionArgs(cmd)
+	mode := e.mode
+	if flagSet(cmd, "help") {
+		mode = helpRun
+	} else if flagSet(cmd, "version") {
+		mode = versionRun
+	}
+	_, ferr := runPhaseHooks(cmd, args, FinallyPhase, mode)
+	if err != nil || ferr == nil || ferr == ErrSkipRun {
+		return err
+	}
+	return runErrorHooks(cmd, FinallyPhase, ferr)
+}
+
+// flagSet reports whether the boolean flag is set on the command line
+func flagSet(cmd *cobra.Command, name string) bool {
+	f := cmd.Flags().Lookup(name)
+	return f != nil && f.Changed && f.Value.String() == "true"
+}
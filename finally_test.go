@@ -0,0 +1,79 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestOnFinally(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	childCmd := &cobra.Command{Use: "child"}
+	rootCmd.AddCommand(childCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	var (
+		ran     []string
+		failRun bool
+	)
+	OnPersistentPreRun(rootCmd, func(_ *cobra.Command, _ []string) error {
+		ran = append(ran, "persPre")
+		return nil
+	})
+	OnRun(childCmd, func(_ *cobra.Command, _ []string) error {
+		ran = append(ran, "run")
+		if failRun {
+			return errors.New("run failed")
+		}
+		return nil
+	})
+	OnFinally(rootCmd, Invoke(func(inv *Invocation) error {
+		entry := "finally(" + inv.Command.Name()
+		if inv.Err != nil {
+			entry += ": " + inv.Err.Error()
+		}
+		if inv.IsHelp {
+			entry += ", help"
+		}
+		ran = append(ran, entry+")")
+		return nil
+	}))
+
+	run := func(args ...string) (string, error) {
+		ran = nil
+		SetArgs(rootCmd, args)
+		err := Execute(rootCmd)
+		return strings.Join(ran, " "), err
+	}
+
+	if got, err := run("child"); err != nil || got != "persPre run finally(child)" {
+		t.Errorf("Unexpected hooks %q, %v", got, err)
+	}
+	failRun = true
+	if got, err := run("child"); err == nil || got != "persPre run finally(child: run failed)" {
+		t.Errorf("Expected the Finally hooks to run when the command fails, got %q, %v", got, err)
+	}
+	if got, err := run("child", "--unknown"); err == nil || got != "finally(child: unknown flag: --unknown)" {
+		t.Errorf("Expected the Finally hooks to run for invalid flags, got %q, %v", got, err)
+	}
+	if got, err := run("child", "--help"); err != nil || got != "finally(child, help)" {
+		t.Errorf("Expected the Finally hooks to run for help, got %q, %v", got, err)
+	}
+}
+
+func TestOnFinallyError(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root", Run: emptyRun}
+	rootCmd.SetOut(new(bytes.Buffer))
+	OnFinally(rootCmd, func(_ *cobra.Command, _ []string) error {
+		return errors.New("cleanup failed")
+	})
+	SetArgs(rootCmd, []string{})
+	if err := Execute(rootCmd); err == nil || err.Error() != "cleanup failed" {
+		t.Errorf("Expected the error of the Finally hook, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type hookGraphHook struct {
+	Phase        Phase    `json:"phase"`
+	Name         string   `json:"name,omitempty"`
+	Labels       []string `json:"labels,omitempty"`
+	Func         string   `json:"func"`
+	File         string   `json:"file"`
+	Line         int      `json:"line"`
+	RunOnHelp    bool     `json:"runOnHelp,omitempty"`
+	RunOnVersion bool     `json:"runOnVersion,omitempty"`
+	Persistent   bool     `json:"persistent,omitempty"`
+}
+
+type hookGraphNode struct {
+	Command   string           `json:"command"`
+	Hooks     []hookGraphHook  `json:"hooks,omitempty"`
+	Conflicts []string         `json:"conflicts,omitempty"`
+	Commands  []*hookGraphNode `json:"commands,omitempty"`
+}
+
+// AddHooksCommand adds a hidden "hooks" command to the root command that
+// prints the hooks registered on every command of the tree, together with
+// the user-defined (Persistent)(Pre/Post)Run(E) fields that conflict with
+// the hooks. Use the --json flag to print the graph as JSON.
+//
+// The command does not run the PersistentPreRun and PersistentPostRun hooks
+// of its parents.
+func AddHooksCommand(root *cobra.Command) *cobra.Command {
+	var asJSON bool
+	hooksCmd := &cobra.Command{
+		Use:    "hooks",
+		Short:  "Print the hooks registered on the commands",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		// Shadow the persistent hooks of the parents
+		PersistentPreRun:  func(_ *cobra.Command, _ []string) {},
+		PersistentPostRun: func(_ *cobra.Command, _ []string) {},
+	}
+	hooksCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		graph := hookGraph(cmd.Root(), hooksCmd)
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(graph)
+		}
+		printHookGraph(cmd.OutOrStdout(), graph, "")
+		return nil
+	}
+	hooksCmd.Flags().BoolVar(&asJSON, "json", false, "print the hooks as JSON")
+	root.AddCommand(hooksCmd)
+	return hooksCmd
+}
+
+// hookGraph builds the hook graph of the command and its childs
+func hookGraph(c *cobra.Command, skip *cobra.Command) *hookGraphNode {
+	node := &hookGraphNode{
+		Command:   c.Name(),
+		Conflicts: conflicts(c),
+	}
+	for _, h := range Hooks(c) {
+		if h.Inherited {
+			continue
+		}
+		node.Hooks = append(node.Hooks, hookGraphHook{
+			Phase:        h.Phase,
+			Name:         h.Name,
+			Labels:       h.Labels,
+			Func:         h.Func,
+			File:         h.File,
+			Line:         h.Line,
+			RunOnHelp:    h.RunOnHelp,
+			RunOnVersion: h.RunOnVersion,
+			Persistent:   h.Persistent,
+		})
+	}
+	for _, child := range c.Commands() {
+		if child == skip {
+			continue
+		}
+		node.Commands = append(node.Commands, hookGraph(child, skip))
+	}
+	return node
+}
+
+func printHookGraph(w io.Writer, node *hookGraphNode, indent string) {
+	fmt.Fprintf(w, "%s%s\n", indent, node.Command)
+	for _, h := range node.Hooks {
+		var opts []string
+		if h.RunOnHelp {
+			opts = append(opts, "run on help")
+		}
+		if h.RunOnVersion {
+			opts = append(opts, "run on version")
+		}
+		if h.Persistent && h.Phase != PersistentPreRunPhase && h.Phase != PersistentPostRunPhase {
+			opts = append(opts, "persistent")
+		}
+		if len(h.Labels) > 0 {
+			opts = append(opts, "labels: "+strings.Join(h.Labels, " "))
+		}
+		name := h.Func
+		if h.Name != "" {
+			name = h.Name
+		}
+		fmt.Fprintf(w, "%s  %-18s %s (%s:%d)", indent, h.Phase, name, filepath.Base(h.File), h.Line)
+		if len(opts) > 0 {
+			fmt.Fprintf(w, " [%s]", strings.Join(opts, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	for _, c := range node.Conflicts {
+		fmt.Fprintf(w, "%s  ! %s\n", indent, c)
+	}
+	for _, child := range node.Commands {
+		printHookGraph(w, child, indent+"  ")
+	}
+}
+
+// lifecycleFields reports which of the command's fields for the phase are set
+func lifecycleFields(c *cobra.Command, p Phase) (hasE bool, hasPlain bool) {
+	switch p {
+	case PersistentPreRunPhase:
+		return c.PersistentPreRunE != nil, c.PersistentPreRun != nil
+	case PreRunPhase:
+		return c.PreRunE != nil, c.PreRun != nil
+	case RunPhase:
+		return c.RunE != nil, c.Run != nil
+	case PostRunPhase:
+		return c.PostRunE != nil, c.PostRun != nil
+	case PersistentPostRunPhase:
+		return c.PersistentPostRunE != nil, c.PersistentPostRun != nil
+	}
+	return false, false
+}
+
+// conflicts describes the user-defined fields of the command that conflict with the registered hooks
+func conflicts(c *cobra.Command) []string {
+	var conflicts []string
+	for p := PersistentPreRunPhase; p <= PersistentPostRunPhase; p++ {
+		hasE, hasPlain := lifecycleFields(c, p)
+		if isDispatcher(c, p) {
+			if hasPlain {
+				conflicts = append(conflicts, fmt.Sprintf("%s is user-defined and ignored in favor of the %sE hooks", p, p))
+			}
+			continue
+		}
+		if !hasE && !hasPlain {
+			continue
+		}
+		field := p.String()
+		if hasE {
+			field += "E"
+		}
+		var local, inherited int
+		for _, ch := range hookChain(c, p, normalRun) {
+			if ch.cmd == c {
+				local++
+			} else {
+				inherited++
+			}
+		}
+		if local > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("%s is user-defined, the command's %s hooks (%d) do not run", field, p, local))
+		}
+		if inherited > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("%s is user-defined, the inherited %s hooks (%d) do not run", field, p, inherited))
+		}
+	}
+	return conflicts
+}
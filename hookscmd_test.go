@@ -0,0 +1,64 @@
+package cobrahooks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestHooksCommand(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Run: emptyRun}}
+	childCmd := &Command{&cobra.Command{Use: "child", Run: emptyRun}}
+	conflictCmd := &Command{&cobra.Command{
+		Use:              "conflict",
+		RunE:             func(_ *cobra.Command, _ []string) error { return nil },
+		PersistentPreRun: emptyRun,
+	}}
+	rootCmd.AddCommand(childCmd.Command, conflictCmd.Command)
+	AddHooksCommand(rootCmd.Command)
+
+	var ran bool
+	rootCmd.OnPersistentPreRun(func(_ *cobra.Command, _ []string) error {
+		ran = true
+		return nil
+	}, RunOnHelp)
+	childCmd.OnRun(noopHook)
+	childCmd.OnHelp(noopHook, Persistent)
+	conflictCmd.OnRun(noopHook)
+
+	output, err := executeCommand(rootCmd.Command, "hooks")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if ran {
+		t.Errorf("Expected the PersistentPreRun hooks of the parents not to run")
+	}
+	checkStringContains(t, output, "root\n  PersistentPreRun   github.com/bartdeboer/cobrahooks.TestHooksCommand.func")
+	checkStringContains(t, output, "(hookscmd_test.go:22) [run on help]\n")
+	checkStringContains(t, output, "  child\n    Run                github.com/bartdeboer/cobrahooks.noopHook (hookscmd_test.go:26)\n")
+	checkStringContains(t, output, "    Help               github.com/bartdeboer/cobrahooks.noopHook (hookscmd_test.go:27) [persistent]\n")
+	checkStringContains(t, output, "    ! Run is user-defined and ignored in favor of the RunE hooks\n")
+	checkStringContains(t, output, "  conflict\n")
+	checkStringContains(t, output, "    ! PersistentPreRun is user-defined, the inherited PersistentPreRun hooks (1) do not run\n")
+	checkStringContains(t, output, "    ! RunE is user-defined, the command's Run hooks (1) do not run\n")
+	checkStringOmits(t, output, "  hooks\n")
+
+	output, err = executeCommand(rootCmd.Command, "hooks", "--json")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	var graph hookGraphNode
+	if err := json.Unmarshal([]byte(output), &graph); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if graph.Command != "root" || len(graph.Hooks) != 1 || len(graph.Commands) != 3 /* child, conflict, help */ {
+		t.Fatalf("Unexpected graph: %+v", graph)
+	}
+	if graph.Hooks[0].Phase != PersistentPreRunPhase || !graph.Hooks[0].RunOnHelp {
+		t.Errorf("Unexpected root hook: %+v", graph.Hooks[0])
+	}
+	if len(graph.Commands[1].Conflicts) != 2 {
+		t.Errorf("Expected 2 conflicts, got %v", graph.Commands[1].Conflicts)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// HookInfo describes a hook registered through this package.
+type HookInfo struct {
+	// Phase is the phase the hook runs in
+	Phase Phase
+	// Command is the command the hook is registered on
+	Command *cobra.Command
+	// Inherited is set when the hook is registered on one of the parents
+	Inherited bool
+
+	RunOnHelp    bool
+	RunOnVersion bool
+	Persistent   bool
+
+	// Name and Labels are set with the Name and Labels options
+	Name   string
+	Labels []string
+	// Func is the name of the hook function
+	Func string
+	// File and Line locate where the hook was registered
+	File string
+	Line int
+}
+
+// Hooks returns a description of every hook that affects the command,
+// in the order they are executed: the PersistentPreRun, PreRun, Run,
+// PostRun and PersistentPostRun hooks followed by the Help, Version,
+// Finally and Error hooks.
+func Hooks(cmd *cobra.Command) []HookInfo {
+	var infos []HookInfo
+	for p := PersistentPreRunPhase; p < numPhases; p++ {
+		mode := normalRun
+		if p == HelpPhase {
+			mode = helpRun
+		} else if p == VersionPhase {
+			mode = versionRun
+		}
+		for _, ch := range hookChain(cmd, p, mode) {
+			infos = append(infos, ch.info(cmd))
+		}
+	}
+	return infos
+}
+
+// info describes the hook as it applies to the command
+func (ch *commandHook) info(cmd *cobra.Command) HookInfo {
+	return HookInfo{
+		Phase:        ch.phase,
+		Command:      ch.cmd,
+		Inherited:    ch.cmd != cmd,
+		RunOnHelp:    ch.runOnHelp,
+		RunOnVersion: ch.runOnVersion,
+		Persistent:   ch.persistent,
+		Name:         ch.name,
+		Labels:       ch.labels,
+		Func:         ch.funcName(),
+		File:         ch.file,
+		Line:         ch.line,
+	}
+}
+
+// funcName returns the name of the hook function
+func (ch *commandHook) funcName() string {
+	if ch.rewrite != nil {
+		return funcName(ch.rewrite)
+	}
+	if ch.onError != nil {
+		return funcName(ch.onError)
+	}
+	return funcName(ch.hook)
+}
+
+func funcName(fn interface{}) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+var packagePrefix = reflect.TypeOf(commandHook{}).PkgPath() + "."
+
+// registrationSite returns the location of the first caller outside this package
+func registrationSite() (file string, line int) {
+	pc := make([]uintptr, 16)
+	n := runtime.Callers(2, pc)
+	frames := runtime.CallersFrames(pc[:n])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, packagePrefix) || strings.HasSuffix(frame.File, "_test.go") {
+			return frame.File, frame.Line
+		}
+		if !more {
+			return "", 0
+		}
+	}
+}
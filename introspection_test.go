@@ -0,0 +1,67 @@
+package cobrahooks
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func noopHook(_ *cobra.Command, _ []string) error { return nil }
+
+func TestHookIntrospection(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Run: emptyRun}}
+	childCmd := &Command{&cobra.Command{Use: "child", Run: emptyRun}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	childCmd.OnPersistentPreRun(noopHook)
+	rootCmd.OnPersistentPreRun(noopHook, RunOnHelp)
+	childCmd.OnPreRun(noopHook)
+	childCmd.OnRun(noopHook)
+	rootCmd.OnRun(noopHook)
+	childCmd.OnPostRun(noopHook)
+	rootCmd.OnPersistentPostRun(noopHook)
+	childCmd.OnPersistentPostRun(noopHook)
+	rootCmd.OnHelp(noopHook, Persistent)
+	rootCmd.OnHelp(noopHook)
+
+	hooks := Hooks(childCmd.Command)
+
+	expected := []struct {
+		phase     Phase
+		cmd       *cobra.Command
+		inherited bool
+	}{
+		{PersistentPreRunPhase, rootCmd.Command, true},
+		{PersistentPreRunPhase, childCmd.Command, false},
+		{PreRunPhase, childCmd.Command, false},
+		{RunPhase, childCmd.Command, false},
+		{PostRunPhase, childCmd.Command, false},
+		{PersistentPostRunPhase, childCmd.Command, false},
+		{PersistentPostRunPhase, rootCmd.Command, true},
+		{HelpPhase, rootCmd.Command, true},
+	}
+	if len(hooks) != len(expected) {
+		t.Fatalf("Expected %d hooks, got %d: %+v", len(expected), len(hooks), hooks)
+	}
+	for i, e := range expected {
+		h := hooks[i]
+		if h.Phase != e.phase || h.Command != e.cmd || h.Inherited != e.inherited {
+			t.Errorf("Hook %d: expected %s on %s (inherited %v), got %s on %s (inherited %v)",
+				i, e.phase, e.cmd.Name(), e.inherited, h.Phase, h.Command.Name(), h.Inherited)
+		}
+		if filepath.Base(h.File) != "introspection_test.go" || h.Line == 0 {
+			t.Errorf("Hook %d: unexpected registration site %s:%d", i, h.File, h.Line)
+		}
+		if !strings.HasSuffix(h.Func, ".noopHook") {
+			t.Errorf("Hook %d: unexpected func %q", i, h.Func)
+		}
+	}
+	if !hooks[0].RunOnHelp || hooks[1].RunOnHelp {
+		t.Errorf("Expected only the root PersistentPreRun hook to run on help")
+	}
+	if !hooks[7].Persistent {
+		t.Errorf("Expected the Help hook to be persistent")
+	}
+}
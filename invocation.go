@@ -0,0 +1,94 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Invocation describes the execution of a command to the hooks registered with Invoke.
+type Invocation struct {
+	// Command is the command being executed
+	Command *cobra.Command
+	// Argv holds the command line arguments the root command was executed with
+	Argv []string
+	// Args holds the arguments of the command, as rewritten by earlier hooks
+	Args []string
+	// Phase is the phase of the hook
+	Phase Phase
+	// Start is the time the execution started
+	Start time.Time
+	// Chain holds the root command down to the command being executed
+	Chain []*cobra.Command
+	// Changed holds the names of the flags set on the command line
+	Changed map[string]bool
+	// IsHelp and IsVersion report whether the hook runs because help or the
+	// version flag was invoked
+	IsHelp    bool
+	IsVersion bool
+	// IsCompletion reports whether the shell requests completions
+	IsCompletion bool
+	// Store holds values shared by the hooks during the execution
+	Store map[string]interface{}
+	// Err is the error the execution failed with, for the Finally hooks
+	Err error
+}
+
+// Invoke adapts a hook that receives an Invocation, so it can be registered
+// with the On* functions:
+//
+//	cobrahooks.OnPersistentPreRun(rootCmd, cobrahooks.Invoke(func(inv *cobrahooks.Invocation) error {
+//	    inv.Store["config"] = loadConfig(inv.Changed["config"])
+//	    return nil
+//	}))
+func Invoke(h func(inv *Invocation) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return h(newInvocation(cmd, args))
+	}
+}
+
+var argvs = make(map[*cobra.Command][]string)
+
+// SetArgs sets the arguments of the root command like its SetArgs method
+// does, and records them as the Argv of the invocations. Without it, the Argv
+// holds the arguments of the process.
+func SetArgs(root *cobra.Command, args []string) {
+	root.SetArgs(args)
+	argvs[root] = args
+}
+
+func newInvocation(cmd *cobra.Command, args []string) *Invocation {
+	e := currentExecution(cmd)
+	argv, ok := argvs[cmd.Root()]
+	if !ok {
+		argv = os.Args[1:]
+	}
+	inv := &Invocation{
+		Command:   cmd,
+		Argv:      argv,
+		Args:      args,
+		Phase:     e.phase,
+		Start:     e.start,
+		Changed:   make(map[string]bool),
+		IsHelp:    e.mode == helpRun,
+		IsVersion: e.mode == versionRun,
+		Store:     e.store,
+		Err:       e.err,
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		inv.Chain = append([]*cobra.Command{c}, inv.Chain...)
+		if c.Name() == cobra.ShellCompRequestCmd || c.Name() == cobra.ShellCompNoDescRequestCmd {
+			inv.IsCompletion = true
+		}
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		inv.Changed[f.Name] = true
+	})
+	return inv
+}
@@ -0,0 +1,79 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestInvoke(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	childCmd := &cobra.Command{Use: "child", Run: emptyRun}
+	rootCmd.AddCommand(childCmd)
+	rootCmd.PersistentFlags().Bool("verbose", false, "")
+	childCmd.Flags().String("name", "", "")
+	childCmd.Flags().Int("count", 0, "")
+
+	var invs []*Invocation
+	record := Invoke(func(inv *Invocation) error {
+		invs = append(invs, inv)
+		return nil
+	})
+	OnPersistentPreRun(rootCmd, Invoke(func(inv *Invocation) error {
+		inv.Store["user"] = "alice"
+		return nil
+	}), RunOnHelp)
+	OnRewriteArgs(childCmd, func(cmd *cobra.Command, args []string) ([]string, error) {
+		return append(args, "extra"), nil
+	})
+	OnRun(childCmd, record)
+	OnHelp(childCmd, record)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	argv := []string{"child", "--verbose", "--name=x", "arg"}
+	SetArgs(rootCmd, argv)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(invs) != 1 {
+		t.Fatalf("Expected 1 invocation, got %d", len(invs))
+	}
+	inv := invs[0]
+	if inv.Command != childCmd || inv.Phase != RunPhase || inv.IsHelp || inv.IsCompletion {
+		t.Errorf("Unexpected invocation: %+v", inv)
+	}
+	if !reflect.DeepEqual(inv.Argv, argv) {
+		t.Errorf("Expected argv %q, got %q", argv, inv.Argv)
+	}
+	if !reflect.DeepEqual(inv.Args, []string{"arg", "extra"}) {
+		t.Errorf("Expected the rewritten args, got %q", inv.Args)
+	}
+	if !reflect.DeepEqual(inv.Chain, []*cobra.Command{rootCmd, childCmd}) {
+		t.Errorf("Expected the chain root child, got %v", inv.Chain)
+	}
+	if !reflect.DeepEqual(inv.Changed, map[string]bool{"verbose": true, "name": true}) {
+		t.Errorf("Expected verbose and name to be changed, got %v", inv.Changed)
+	}
+	if inv.Store["user"] != "alice" {
+		t.Errorf("Expected the store to be shared between the hooks, got %v", inv.Store)
+	}
+	if inv.Start.IsZero() {
+		t.Errorf("Expected the start time to be set")
+	}
+
+	invs = nil
+	SetArgs(rootCmd, []string{"child", "--help"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(invs) != 1 || !invs[0].IsHelp || invs[0].Phase != HelpPhase {
+		t.Fatalf("Expected a help invocation, got %+v", invs)
+	}
+	if invs[0].Store["user"] != "alice" {
+		t.Errorf("Expected the store to be shared during the help run, got %v", invs[0].Store)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// Isolate replaces the registered hooks, the disabled hooks, the observers,
+// the signal handlers and the arguments set with SetArgs with empty ones,
+// until the returned restore function is called. Hooks
+// registered before are not run in the meantime, and hooks registered in the
+// meantime are dropped by restore. It is meant for tests, see the
+// cobrahookstest package, and is not safe for concurrent use.
+func Isolate() (restore func()) {
+	prevRegistered, prevDisabled, prevObservers, prevCurrent := registered, disabled, observers, current
+	prevSignalHandlers, prevArgvs := signalHandlers, argvs
+	registered = newRegistry()
+	disabled = make(map[string]bool)
+	observers = nil
+	current = nil
+	signalHandlers = make(map[*cobra.Command]*SignalHandler)
+	argvs = make(map[*cobra.Command][]string)
+	return func() {
+		registered, disabled, observers, current = prevRegistered, prevDisabled, prevObservers, prevCurrent
+		signalHandlers, argvs = prevSignalHandlers, prevArgvs
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Name names the hook. The name appears in the errors returned by the hook,
+// in the introspection and the tracing, and can be used to look up and
+// order the hook from other packages.
+func Name(name string) func(*HookOptions) {
+	return func(o *HookOptions) { o.name = name }
+}
+
+// Labels attaches free-form labels to the hook (e.g. "telemetry").
+func Labels(labels ...string) func(*HookOptions) {
+	return func(o *HookOptions) { o.labels = append(o.labels, labels...) }
+}
+
+// Before runs the hook before the hooks of the same phase with the name or label.
+func Before(names ...string) func(*HookOptions) {
+	return func(o *HookOptions) { o.before = append(o.before, names...) }
+}
+
+// After runs the hook after the hooks of the same phase with the name or label.
+func After(names ...string) func(*HookOptions) {
+	return func(o *HookOptions) { o.after = append(o.after, names...) }
+}
+
+// HookError is returned when a named hook fails.
+type HookError struct {
+	Name  string
+	Phase Phase
+	Err   error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("hook %q: %v", e.Name, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// run executes the hook and returns the (rewritten) arguments,
+// the errors of named hooks are wrapped in a HookError
+func (ch *commandHook) run(cmd *cobra.Command, args []string) ([]string, error) {
+	var err error
+	if ch.rewrite != nil {
+		var newArgs []string
+		if newArgs, err = ch.rewrite(cmd, args); err == nil {
+			args = newArgs
+		}
+	} else {
+		err = ch.hook(cmd, args)
+	}
+	if err != nil && ch.name != "" {
+		return args, &HookError{Name: ch.name, Phase: ch.phase, Err: err}
+	}
+	return args, err
+}
+
+// matches reports whether the hook has the name or label
+func (ch *commandHook) matches(name string) bool {
+	if ch.name == name {
+		return true
+	}
+	for _, l := range ch.labels {
+		if l == name {
+			return true
+		}
+	}
+	return false
+}
+
+// displayName returns the name of the hook or else the name of its function
+func (ch *commandHook) displayName() string {
+	if ch.name != "" {
+		return ch.name
+	}
+	return ch.funcName()
+}
+
+// Lookup returns a description of the hooks registered with the name or label.
+func Lookup(name string) []HookInfo {
+	var infos []HookInfo
+	for p := PersistentPreRunPhase; p < numPhases; p++ {
+		for _, ch := range registered.hooks[p] {
+			if ch.matches(name) {
+				infos = append(infos, ch.info(ch.cmd))
+			}
+		}
+	}
+	return infos
+}
+
+// orderChain reorders the chain to satisfy the Before and After options of
+// the hooks, otherwise keeping the execution order
+func orderChain(chain []*commandHook) []*commandHook {
+	ordered := true
+	for _, ch := range chain {
+		if len(ch.before) > 0 || len(ch.after) > 0 {
+			ordered = false
+			break
+		}
+	}
+	if ordered {
+		return chain
+	}
+	// deps[i] holds the hooks that have to run before hook i
+	deps := make([][]int, len(chain))
+	for i, a := range chain {
+		for j, b := range chain {
+			if i == j {
+				continue
+			}
+			for _, name := range a.before {
+				if b.matches(name) {
+					deps[j] = append(deps[j], i)
+				}
+			}
+			for _, name := range a.after {
+				if b.matches(name) {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+	result := make([]*commandHook, 0, len(chain))
+	done := make([]bool, len(chain))
+	for len(result) < len(chain) {
+		next := -1
+		for i := range chain {
+			if done[i] {
+				continue
+			}
+			ready := true
+			for _, d := range deps[i] {
+				if !done[d] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			// Cyclic constraints, keep the execution order of the remaining hooks
+			for i := range chain {
+				if !done[i] {
+					next = i
+					break
+				}
+			}
+		}
+		done[next] = true
+		result = append(result, chain[next])
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package cobrahooks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNamedHooks(t *testing.T) {
+	// Lookup searches all registered hooks
+	defer Isolate()()
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+
+	var order []string
+	hook := func(name string) func(*cobra.Command, []string) error {
+		return func(_ *cobra.Command, _ []string) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	rootCmd.OnPreRun(hook("telemetry"), Name("named-telemetry"), Labels("telemetry", "optional"))
+	rootCmd.OnPreRun(hook("config"), Name("named-load-config"), Before("named-telemetry"))
+	rootCmd.OnPreRun(hook("update-check"), Labels("optional"), After("named-telemetry"))
+	rootCmd.OnPreRun(hook("auth"), Name("named-auth"), After("named-load-config"), Before("telemetry"))
+	rootCmd.OnRun(func(_ *cobra.Command, _ []string) error {
+		return errors.New("failed")
+	}, Name("named-run"))
+
+	_, err := executeCommand(rootCmd.Command)
+	if err == nil || err.Error() != `hook "named-run": failed` {
+		t.Errorf("Expected named hook error, got %v", err)
+	}
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) || hookErr.Name != "named-run" || hookErr.Phase != RunPhase {
+		t.Errorf("Expected HookError, got %#v", err)
+	}
+
+	expected := []string{"config", "auth", "telemetry", "update-check"}
+	if len(order) != len(expected) {
+		t.Fatalf("Expected order %v, got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+
+	infos := Lookup("named-load-config")
+	if len(infos) != 1 || infos[0].Command != rootCmd.Command || infos[0].Phase != PreRunPhase {
+		t.Errorf("Unexpected lookup result: %+v", infos)
+	}
+	infos = Lookup("optional")
+	if len(infos) != 2 || infos[0].Name != "named-telemetry" || infos[1].Name != "" {
+		t.Errorf("Unexpected lookup result: %+v", infos)
+	}
+	if len(Lookup("unknown")) != 0 {
+		t.Errorf("Expected no hooks")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// HookEvent describes the execution of a hook.
+type HookEvent struct {
+	// Command is the command being executed
+	Command *cobra.Command
+	Args    []string
+	// Hook describes the executed hook
+	Hook  HookInfo
+	Start time.Time
+	// Duration and Err are set once the hook returned
+	Duration time.Duration
+	Err      error
+}
+
+// HookObserver is notified before and after every hook is executed.
+type HookObserver interface {
+	BeforeHook(e HookEvent)
+	AfterHook(e HookEvent)
+}
+
+var observers []HookObserver
+
+// AddObserver registers an observer that is notified of every hook execution.
+func AddObserver(o HookObserver) {
+	observers = append(observers, o)
+}
+
+// RemoveObserver unregisters the observer.
+func RemoveObserver(o HookObserver) {
+	for i, obs := range observers {
+		if obs == o {
+			observers = append(observers[:i:i], observers[i+1:]...)
+			return
+		}
+	}
+}
+
+// callHook executes the hook, notifies the observers and returns the (rewritten) arguments
+func callHook(ch *commandHook, cmd *cobra.Command, args []string) ([]string, error) {
+	if len(observers) == 0 {
+		return ch.run(cmd, args)
+	}
+	e := HookEvent{
+		Command: cmd,
+		Args:    args,
+		Hook:    ch.info(cmd),
+		Start:   time.Now(),
+	}
+	for _, o := range observers {
+		o.BeforeHook(e)
+	}
+	newArgs, err := ch.run(cmd, args)
+	e.Duration = time.Since(e.Start)
+	e.Err = err
+	for _, o := range observers {
+		o.AfterHook(e)
+	}
+	return newArgs, err
+}
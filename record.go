@@ -0,0 +1,218 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Recording describes an invocation of a command, as written by Record.
+type Recording struct {
+	// Argv holds the command line arguments
+	Argv []string `json:"argv"`
+	// Env holds the environment variables of the allowlist that were set
+	Env map[string]string `json:"env,omitempty"`
+	// Stdin holds the input, when the command read all of it and it is not
+	// larger than the maximum size
+	Stdin    *string        `json:"stdin,omitempty"`
+	Command  string         `json:"command"`
+	Stdout   string         `json:"stdout"`
+	Stderr   string         `json:"stderr"`
+	Error    string         `json:"error,omitempty"`
+	Start    time.Time      `json:"start"`
+	Duration time.Duration  `json:"duration"`
+	Hooks    []RecordedHook `json:"hooks,omitempty"`
+}
+
+// RecordedHook describes the execution of a hook during a recorded invocation.
+type RecordedHook struct {
+	Phase    Phase         `json:"phase"`
+	Command  string        `json:"command"`
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// RecordOptions configures Record.
+type RecordOptions struct {
+	// Env lists the environment variables to record
+	Env []string
+	// MaxStdin is the maximum size of the input to record, 64KiB by default
+	MaxStdin int
+}
+
+const defaultMaxStdin = 64 << 10
+
+// Record appends every invocation of the root command to the file at path,
+// as one JSON encoded Recording per line: the arguments, the environment
+// variables of the allowlist, the input if it is small, the output, the
+// error and the timing of every hook. The recordings can be attached to bug
+// reports and replayed with the cobrahookstest package.
+//
+// Only the invocations executed through Execute, ExecuteC or ExecuteAndExit
+// are recorded. Failing to write the recording does not fail the invocation,
+// the error is printed to stderr instead. Recording is meant to be opt-in,
+// e.g. with an environment variable:
+//
+//	if path := os.Getenv("MYAPP_RECORD"); path != "" {
+//	    cobrahooks.Record(rootCmd, path, cobrahooks.RecordOptions{Env: []string{"MYAPP_PROFILE"}})
+//	}
+func Record(root *cobra.Command, path string, opts RecordOptions) {
+	if opts.MaxStdin == 0 {
+		opts.MaxStdin = defaultMaxStdin
+	}
+	r := &recorder{root: root, path: path, opts: opts}
+	AddObserver(r)
+	executeWrappers[root] = append(executeWrappers[root], r.start)
+}
+
+type recorder struct {
+	root *cobra.Command
+	path string
+	opts RecordOptions
+	// rec is the recording of the current invocation
+	rec            *Recording
+	stdout, stderr bytes.Buffer
+	stdin          *stdinRecorder
+}
+
+// start starts recording an invocation and returns the function that finishes it
+func (r *recorder) start() func(inv *Invocation) {
+	r.rec = &Recording{}
+	r.stdout.Reset()
+	r.stderr.Reset()
+	out, err, in := r.root.OutOrStdout(), r.root.ErrOrStderr(), r.root.InOrStdin()
+	// Cobra prints the errors to stderr when the output is not set
+	outSet := out == r.root.OutOrStderr()
+	r.root.SetOut(io.MultiWriter(out, &r.stdout))
+	r.root.SetErr(io.MultiWriter(err, &r.stderr))
+	r.stdin = &stdinRecorder{r: in, max: r.opts.MaxStdin}
+	r.root.SetIn(r.stdin)
+	return func(inv *Invocation) {
+		if outSet {
+			r.root.SetOut(out)
+		} else {
+			r.root.SetOut(nil)
+		}
+		r.root.SetErr(err)
+		r.root.SetIn(in)
+		if inv != nil {
+			if err := r.finish(inv); err != nil {
+				fmt.Fprintf(r.root.ErrOrStderr(), "cobrahooks: recording %s: %v\n", r.path, err)
+			}
+		}
+		r.rec = nil
+	}
+}
+
+// finish writes the recording of the invocation
+func (r *recorder) finish(inv *Invocation) error {
+	rec := r.rec
+	rec.Stdout, rec.Stderr = r.stdout.String(), r.stderr.String()
+	if r.stdin.eof && !r.stdin.truncated {
+		stdin := r.stdin.buf.String()
+		rec.Stdin = &stdin
+	}
+	rec.Argv = inv.Argv
+	rec.Command = inv.Command.CommandPath()
+	rec.Start = inv.Start
+	rec.Duration = time.Since(inv.Start)
+	if inv.Err != nil {
+		rec.Error = inv.Err.Error()
+	}
+	for _, name := range r.opts.Env {
+		if v, ok := os.LookupEnv(name); ok {
+			if rec.Env == nil {
+				rec.Env = make(map[string]string)
+			}
+			rec.Env[name] = v
+		}
+	}
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(rec); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// BeforeHook implements HookObserver.
+func (r *recorder) BeforeHook(e HookEvent) {}
+
+// AfterHook implements HookObserver.
+func (r *recorder) AfterHook(e HookEvent) {
+	if r.rec == nil || e.Command.Root() != r.root {
+		return
+	}
+	name := e.Hook.Name
+	if name == "" {
+		name = e.Hook.Func
+	}
+	h := RecordedHook{
+		Phase:    e.Hook.Phase,
+		Command:  e.Hook.Command.CommandPath(),
+		Name:     name,
+		Duration: e.Duration,
+	}
+	if e.Err != nil {
+		h.Error = e.Err.Error()
+	}
+	r.rec.Hooks = append(r.rec.Hooks, h)
+}
+
+// stdinRecorder records the input read by the command, up to a maximum size
+type stdinRecorder struct {
+	r         io.Reader
+	max       int
+	buf       bytes.Buffer
+	eof       bool
+	truncated bool
+}
+
+func (s *stdinRecorder) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if !s.truncated {
+		if s.buf.Len()+n > s.max {
+			s.truncated = true
+			s.buf.Reset()
+		} else {
+			s.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF {
+		s.eof = true
+	}
+	return n, err
+}
+
+// ReadRecordings reads the recordings written by Record.
+func ReadRecordings(r io.Reader) ([]Recording, error) {
+	var recs []Recording
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Recording
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, scanner.Err()
+}
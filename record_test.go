@@ -0,0 +1,128 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobrahooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "record.jsonl")
+
+	rootCmd := &cobra.Command{Use: "root"}
+	catCmd := &cobra.Command{Use: "cat"}
+	rootCmd.AddCommand(catCmd)
+	OnPreRun(catCmd, noopHook, Name("prepare"))
+	OnRun(catCmd, func(cmd *cobra.Command, args []string) error {
+		in, err := ioutil.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		cmd.OutOrStdout().Write(in)
+		if len(args) > 0 {
+			return errors.New(args[0])
+		}
+		return nil
+	})
+	Record(rootCmd, path, RecordOptions{Env: []string{"COBRAHOOKS_TEST_PROFILE"}, MaxStdin: 8})
+
+	os.Setenv("COBRAHOOKS_TEST_PROFILE", "dev")
+	defer os.Unsetenv("COBRAHOOKS_TEST_PROFILE")
+	for _, c := range []struct {
+		stdin string
+		args  []string
+	}{
+		{"hello", []string{"cat"}},
+		{"too much input", []string{"cat", "failed"}},
+	} {
+		out := new(bytes.Buffer)
+		rootCmd.SetOut(out)
+		rootCmd.SetErr(out)
+		rootCmd.SetIn(strings.NewReader(c.stdin))
+		SetArgs(rootCmd, c.args)
+		Execute(rootCmd)
+		checkStringContains(t, out.String(), c.stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	recs, err := ReadRecordings(f)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("Expected 2 recordings, got %d", len(recs))
+	}
+
+	rec := recs[0]
+	if !reflect.DeepEqual(rec.Argv, []string{"cat"}) || rec.Command != "root cat" || rec.Error != "" {
+		t.Errorf("Unexpected recording: %+v", rec)
+	}
+	if rec.Stdin == nil || *rec.Stdin != "hello" || rec.Stdout != "hello" {
+		t.Errorf("Expected the input and output to be recorded, got %+v", rec)
+	}
+	if rec.Env["COBRAHOOKS_TEST_PROFILE"] != "dev" {
+		t.Errorf("Expected the environment to be recorded, got %v", rec.Env)
+	}
+	if len(rec.Hooks) != 2 || rec.Hooks[0].Name != "prepare" || rec.Hooks[1].Phase != RunPhase {
+		t.Errorf("Expected the hooks to be recorded, got %+v", rec.Hooks)
+	}
+
+	rec = recs[1]
+	if rec.Stdin != nil {
+		t.Errorf("Expected the input larger than the maximum not to be recorded, got %q", *rec.Stdin)
+	}
+	if rec.Error != "failed" || rec.Hooks[1].Error != "failed" {
+		t.Errorf("Expected the error to be recorded, got %+v", rec)
+	}
+	checkStringContains(t, rec.Stdout, "too much inputError: failed\n")
+}
+
+func TestRecordStreamsAndErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cobrahooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	rootCmd := &cobra.Command{Use: "root"}
+	OnRun(rootCmd, noopHook)
+	// The directory of the file does not exist
+	path := filepath.Join(dir, "missing", "record.jsonl")
+	Record(rootCmd, path, RecordOptions{})
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	rootCmd.SetErr(out)
+	SetArgs(rootCmd, nil)
+
+	if err := Execute(rootCmd); err != nil {
+		t.Errorf("Expected the recording error not to fail the command, got %v", err)
+	}
+	checkStringContains(t, out.String(), "cobrahooks: recording "+path+": ")
+	if rootCmd.OutOrStdout() != out || rootCmd.ErrOrStderr() != out {
+		t.Error("Expected the streams to be restored")
+	}
+
+	// The streams are only swapped for executions through Execute
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if rootCmd.OutOrStdout() != out {
+		t.Error("Expected the streams not to be swapped")
+	}
+}
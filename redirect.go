@@ -0,0 +1,179 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+const maxRedirects = 10
+
+// RedirectError is returned by Redirect.
+type RedirectError struct {
+	Command *cobra.Command
+	Args    []string
+}
+
+func (r *RedirectError) Error() string {
+	return fmt.Sprintf("redirect to %q", r.Command.CommandPath())
+}
+
+// Redirect can be returned by a PersistentPreRun, PreRun or Run hook to
+// execute another command of the tree instead, with the given arguments
+// (which may include flags). The other command runs through its own hook
+// chain, while the remaining hooks of the redirecting command are skipped.
+// This allows deprecated commands to forward to new ones, or a command to
+// have a default subcommand:
+//
+//	cobrahooks.OnPreRun(oldCmd, func(cmd *cobra.Command, args []string) error {
+//	    return cobrahooks.Redirect(newCmd, args)
+//	})
+//
+// The other command runs with the context of the redirecting command, and
+// shows its help or version when the arguments hold the help or version flag.
+// Note that the PersistentPreRun hooks of the common parents run again for
+// the other command, and that user-defined (Pre/Post)Run(E) fields of the
+// redirecting command still run. A command without a Run field or Run hooks
+// is not runnable, cobra shows its help instead of running its PreRun hooks.
+func Redirect(cmd *cobra.Command, args []string) error {
+	return &RedirectError{Command: cmd, Args: args}
+}
+
+// asRedirect returns the redirect held by the error
+func asRedirect(err error) (*RedirectError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var r *RedirectError
+	ok := errors.As(err, &r)
+	return r, ok
+}
+
+// redirect executes the command of the redirect as part of the execution
+func redirect(from *execution, r *RedirectError) error {
+	if from.redirects >= maxRedirects {
+		return fmt.Errorf("cobrahooks: too many redirects to %q", r.Command.CommandPath())
+	}
+	if debug := debugWriter(); debug != nil {
+		debugf(debug, "redirect %q to %q", from.cmd.CommandPath(), r.Command.CommandPath())
+	}
+	current = newExecution(r.Command)
+	current.redirects = from.redirects + 1
+	// The redirect is part of the same execution
+	current.start, current.store = from.start, from.store
+	defer func() {
+		current = from
+	}()
+	return executeRedirect(from.cmd, r.Command, r.Args)
+}
+
+// executeRedirect runs the command's fields in the same order as cobra does
+func executeRedirect(from, c *cobra.Command, args []string) error {
+	if err := setContext(c, from.Context()); err != nil {
+		return err
+	}
+	if len(c.Deprecated) > 0 {
+		c.Printf("Command %q is deprecated, %s\n", c.Name(), c.Deprecated)
+	}
+	c.InitDefaultHelpFlag()
+	c.InitDefaultVersionFlag()
+	if err := c.ParseFlags(args); err != nil {
+		return c.FlagErrorFunc()(c, err)
+	}
+	if help, _ := c.Flags().GetBool("help"); help {
+		c.HelpFunc()(c, args)
+		return nil
+	}
+	if version, _ := c.Flags().GetBool("version"); version && c.Version != "" {
+		return printVersion(c)
+	}
+	if !c.Runnable() {
+		c.HelpFunc()(c, args)
+		return nil
+	}
+	if !c.DisableFlagParsing {
+		args = c.Flags().Args()
+	}
+	if err := c.ValidateArgs(args); err != nil {
+		return err
+	}
+
+	for p := c; p != nil; p = p.Parent() {
+		if p.PersistentPreRunE != nil {
+			if err := p.PersistentPreRunE(c, args); err != nil {
+				return err
+			}
+			break
+		} else if p.PersistentPreRun != nil {
+			p.PersistentPreRun(c, args)
+			break
+		}
+	}
+	if c.PreRunE != nil {
+		if err := c.PreRunE(c, args); err != nil {
+			return err
+		}
+	} else if c.PreRun != nil {
+		c.PreRun(c, args)
+	}
+	if err := requiredFlagsError(c); err != nil {
+		return &UsageError{Err: err}
+	}
+	if c.RunE != nil {
+		if err := c.RunE(c, args); err != nil {
+			return err
+		}
+	} else {
+		c.Run(c, args)
+	}
+	if c.PostRunE != nil {
+		if err := c.PostRunE(c, args); err != nil {
+			return err
+		}
+	} else if c.PostRun != nil {
+		c.PostRun(c, args)
+	}
+	for p := c; p != nil; p = p.Parent() {
+		if p.PersistentPostRunE != nil {
+			if err := p.PersistentPostRunE(c, args); err != nil {
+				return err
+			}
+			break
+		} else if p.PersistentPostRun != nil {
+			p.PersistentPostRun(c, args)
+			break
+		}
+	}
+	return nil
+}
+
+// printVersion prints the version of the command like cobra does. Cobra only
+// renders templates with its template functions (including those added with
+// cobra.AddTemplateFunc) in its default usage and help functions, so the
+// version template is rendered as the usage template of the command.
+func printVersion(c *cobra.Command) error {
+	usageTemplate := c.UsageTemplate()
+	inheritedTemplate := c.HasParent() && usageTemplate == c.Parent().UsageTemplate()
+	out := c.OutOrStdout()
+	// An output is set when it is used for the errors as well
+	inheritedOut := out != c.OutOrStderr() || c.HasParent() && out == c.Parent().OutOrStderr()
+	defer func() {
+		if inheritedTemplate {
+			usageTemplate = ""
+		}
+		c.SetUsageTemplate(usageTemplate)
+		if inheritedOut {
+			out = nil
+		}
+		c.SetOut(out)
+	}()
+	c.SetUsageTemplate(c.VersionTemplate())
+	// The default usage function prints to the output of the errors
+	c.SetOut(out)
+	return new(cobra.Command).UsageFunc()(c)
+}
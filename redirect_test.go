@@ -0,0 +1,172 @@
+package cobrahooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestRedirect(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	oldCmd := &Command{&cobra.Command{Use: "old"}}
+	newCmd := &Command{&cobra.Command{Use: "new", Args: cobra.MinimumNArgs(1)}}
+	rootCmd.AddCommand(oldCmd.Command, newCmd.Command)
+
+	var (
+		ran   []string
+		force bool
+	)
+	newCmd.Flags().BoolVar(&force, "force", false, "")
+	hook := func(name string) func(*cobra.Command, []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			ran = append(ran, name+"("+cmd.Name()+":"+strings.Join(args, ",")+")")
+			return nil
+		}
+	}
+
+	rootCmd.OnPersistentPreRun(hook("persPre"))
+	rootCmd.OnPersistentPostRun(hook("persPost"))
+	// The root command defaults to the new command
+	rootCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		return Redirect(newCmd.Command, append([]string{"default"}, args...))
+	})
+	rootCmd.OnRun(hook("rootRun"))
+	// The old command forwards to the new command
+	oldCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		return Redirect(newCmd.Command, append(args, "--force"))
+	}, Name("forward"))
+	oldCmd.OnRun(hook("oldRun"))
+	oldCmd.OnPostRun(hook("oldPost"))
+	newCmd.OnPreRun(hook("newPre"))
+	newCmd.OnRun(hook("newRun"))
+
+	run := func(args ...string) string {
+		ran = nil
+		force = false
+		if _, err := executeCommand(rootCmd.Command, args...); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		return strings.Join(ran, " ")
+	}
+
+	expected := "persPre(old:a) persPre(new:a) newPre(new:a) newRun(new:a) persPost(new:a)"
+	if got := run("old", "a"); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+	if !force {
+		t.Errorf("Expected the force flag to be parsed")
+	}
+
+	expected = "persPre(root:) persPre(new:default) newPre(new:default) newRun(new:default) persPost(new:default)"
+	if got := run(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	// The arguments are validated for the new command
+	ran = nil
+	output, err := executeCommand(rootCmd.Command, "old")
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+	checkStringContains(t, output, "requires at least 1 arg(s)")
+	if got := strings.Join(ran, " "); got != "persPre(old:)" {
+		t.Errorf("Expected %q, got %q", "persPre(old:)", got)
+	}
+}
+
+type ctxKey struct{}
+
+func TestRedirectContextHelpAndVersion(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	oldCmd := &Command{&cobra.Command{Use: "old"}}
+	newCmd := &Command{&cobra.Command{Use: "new", Version: "1.2.3"}}
+	rootCmd.AddCommand(oldCmd.Command, newCmd.Command)
+
+	var (
+		redirectArgs []string
+		ctxValue     interface{}
+		ran          bool
+	)
+	oldCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		return Redirect(newCmd.Command, redirectArgs)
+	})
+	oldCmd.OnRun(noopHook)
+	newCmd.OnRun(func(cmd *cobra.Command, args []string) error {
+		ran = true
+		if ctx := cmd.Context(); ctx != nil {
+			ctxValue = ctx.Value(ctxKey{})
+		}
+		return nil
+	})
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	if _, err := executeCommandWithContext(ctx, rootCmd.Command, "old"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if ctxValue != "value" {
+		t.Errorf("Expected the context of the redirecting command, got %v", ctxValue)
+	}
+
+	ran = false
+	redirectArgs = []string{"--help"}
+	output, err := executeCommand(rootCmd.Command, "old")
+	if err != nil || ran {
+		t.Errorf("Expected the help instead of the run, got %v, %v", ran, err)
+	}
+	checkStringContains(t, output, "root new [flags]")
+	newCmd.Flags().Set("help", "false")
+
+	redirectArgs = []string{"--version"}
+	output, err = executeCommand(rootCmd.Command, "old")
+	if err != nil || ran {
+		t.Errorf("Expected the version instead of the run, got %v, %v", ran, err)
+	}
+	checkStringContains(t, output, "new version 1.2.3")
+}
+
+func TestRedirectRequiredFlagsAndTemplates(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	oldCmd := &Command{&cobra.Command{Use: "old"}}
+	newCmd := &Command{&cobra.Command{Use: "new", Version: " 1.2.3 ", Deprecated: "use \"other\" instead"}}
+	rootCmd.AddCommand(oldCmd.Command, newCmd.Command)
+	newCmd.Flags().String("name", "", "")
+	newCmd.MarkFlagRequired("name")
+
+	var (
+		redirectArgs []string
+		ran          bool
+	)
+	oldCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		return Redirect(newCmd.Command, redirectArgs)
+	})
+	oldCmd.OnRun(noopHook)
+	newCmd.OnRun(func(cmd *cobra.Command, args []string) error {
+		ran = true
+		return nil
+	})
+
+	output, err := executeCommand(rootCmd.Command, "old")
+	if err == nil || err.Error() != `required flag(s) "name" not set` || !IsUsageError(err) || ran {
+		t.Errorf("Expected the required flag error, got %v, %v", ran, err)
+	}
+	checkStringContains(t, output, `Command "new" is deprecated, use "other" instead`)
+
+	redirectArgs = []string{"--name=x"}
+	if _, err := executeCommand(rootCmd.Command, "old"); err != nil || !ran {
+		t.Errorf("Expected the command to run, got %v, %v", ran, err)
+	}
+
+	cobra.AddTemplateFunc("redirectTestUpper", strings.ToUpper)
+	newCmd.SetVersionTemplate(`{{redirectTestUpper .Name}} {{trim .Version}}` + "\n")
+	redirectArgs = []string{"--version"}
+	output, err = executeCommand(rootCmd.Command, "old")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "NEW 1.2.3\n")
+	if newCmd.UsageTemplate() != rootCmd.UsageTemplate() {
+		t.Error("Expected the usage template to be restored")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// registry holds the registered hooks, indexed by command and phase, and
+// caches the hook chains of the commands
+type registry struct {
+	// hooks holds the hooks of every phase in registration order
+	hooks [numPhases][]*commandHook
+	// commands holds the hooks registered on every command
+	commands map[*cobra.Command]*[numPhases][]*commandHook
+	// chains caches the hook chains, it is reset on registration
+	chains map[chainKey]*cachedChain
+}
+
+type chainKey struct {
+	cmd   *cobra.Command
+	phase Phase
+	mode  runMode
+}
+
+type cachedChain struct {
+	// path holds the command and its parents the chain was computed for
+	path  []*cobra.Command
+	hooks []*commandHook
+}
+
+var registered = newRegistry()
+
+func newRegistry() *registry {
+	return &registry{
+		commands: make(map[*cobra.Command]*[numPhases][]*commandHook),
+	}
+}
+
+// add registers the hook
+func (r *registry) add(ch *commandHook) {
+	r.hooks[ch.phase] = append(r.hooks[ch.phase], ch)
+	cmdHooks, ok := r.commands[ch.cmd]
+	if !ok {
+		cmdHooks = new([numPhases][]*commandHook)
+		r.commands[ch.cmd] = cmdHooks
+	}
+	cmdHooks[ch.phase] = append(cmdHooks[ch.phase], ch)
+	r.chains = nil
+}
+
+// commandHooks returns the hooks of the phase registered on the command
+func (r *registry) commandHooks(c *cobra.Command, p Phase) []*commandHook {
+	if cmdHooks, ok := r.commands[c]; ok {
+		return cmdHooks[p]
+	}
+	return nil
+}
+
+// chain returns the hooks of the phase that apply to the command in the
+// order they are executed, regardless of whether they are disabled
+func (r *registry) chain(cmd *cobra.Command, p Phase, mode runMode) []*commandHook {
+	key := chainKey{cmd, p, mode}
+	if cached, ok := r.chains[key]; ok && cached.matches(cmd) {
+		return cached.hooks
+	}
+	cached := &cachedChain{}
+	for c := cmd; c != nil; c = c.Parent() {
+		cached.path = append(cached.path, c)
+	}
+	forEachCandidate(cmd, p, func(ch *commandHook) {
+		if ch.chainSkipReason(cmd, mode) == "" {
+			cached.hooks = append(cached.hooks, ch)
+		}
+	})
+	cached.hooks = orderChain(cached.hooks)
+	if r.chains == nil {
+		r.chains = make(map[chainKey]*cachedChain)
+	}
+	r.chains[key] = cached
+	return cached.hooks
+}
+
+// matches reports whether the command still has the parents the chain was computed for
+func (cc *cachedChain) matches(cmd *cobra.Command) bool {
+	i := 0
+	for c := cmd; c != nil; c = c.Parent() {
+		if i >= len(cc.path) || cc.path[i] != c {
+			return false
+		}
+		i++
+	}
+	return i == len(cc.path)
+}
@@ -0,0 +1,70 @@
+package cobrahooks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// wideTree builds a root command with n childs, every command having a hook for each phase.
+// The hooks are registered globally, isolate them with Isolate.
+func wideTree(n int) (root *cobra.Command, childs []*cobra.Command) {
+	hook := func(_ *cobra.Command, _ []string) error { return nil }
+	root = &cobra.Command{Use: "root"}
+	OnPersistentPreRun(root, hook)
+	OnPersistentPostRun(root, hook)
+	for i := 0; i < n; i++ {
+		child := &cobra.Command{Use: fmt.Sprintf("child%d", i)}
+		root.AddCommand(child)
+		OnPersistentPreRun(child, hook)
+		OnPreRun(child, hook)
+		OnRun(child, hook)
+		OnPostRun(child, hook)
+		OnPersistentPostRun(child, hook)
+		childs = append(childs, child)
+	}
+	return root, childs
+}
+
+func TestChainCacheInvalidation(t *testing.T) {
+	defer Isolate()()
+	root, childs := wideTree(3)
+	child := childs[1]
+	if n := len(hookChain(child, PersistentPreRunPhase, normalRun)); n != 2 {
+		t.Errorf("Expected 2 PersistentPreRun hooks, got %d", n)
+	}
+	// Registering a hook invalidates the cached chains
+	OnPersistentPreRun(root, noopHook)
+	if n := len(hookChain(child, PersistentPreRunPhase, normalRun)); n != 3 {
+		t.Errorf("Expected 3 PersistentPreRun hooks after registering one, got %d", n)
+	}
+	// Moving a command to another parent changes its chain
+	root.RemoveCommand(child)
+	other := &cobra.Command{Use: "other"}
+	other.AddCommand(child)
+	if n := len(hookChain(child, PersistentPreRunPhase, normalRun)); n != 1 {
+		t.Errorf("Expected 1 PersistentPreRun hook after moving the command, got %d", n)
+	}
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("commands=%d", n), func(b *testing.B) {
+			defer Isolate()()
+			_, childs := wideTree(n)
+			child := childs[n/2]
+			args := []string{}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resetExecution()
+				for p := PersistentPreRunPhase; p <= PersistentPostRunPhase; p++ {
+					if err := dispatch(child, args, p); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// DefaultGracePeriod is the grace period of the signal handlers.
+const DefaultGracePeriod = 10 * time.Second
+
+// SignalHandler cancels the context of the executions of a root command on a
+// signal, see HandleSignals.
+type SignalHandler struct {
+	// GracePeriod is the time the execution has to finish after the first
+	// signal, including the PersistentPostRun and Finally hooks
+	GracePeriod time.Duration
+
+	signals []os.Signal
+	ctx     *signalContext
+	// notify is the channel the signals of the current execution are delivered on
+	notify chan os.Signal
+
+	mu          sync.Mutex
+	interrupted os.Signal
+	done        chan struct{}
+}
+
+var signalHandlers = make(map[*cobra.Command]*SignalHandler)
+
+// HandleSignals handles the signals, os.Interrupt by default, during the
+// executions of the root command through Execute, ExecuteC and
+// ExecuteAndExit. The commands get a context (see the Context method of
+// cobra.Command) that is canceled on the first signal:
+//
+//	cobrahooks.HandleSignals(rootCmd, os.Interrupt, syscall.SIGTERM)
+//	cobrahooks.OnRun(serveCmd, func(cmd *cobra.Command, args []string) error {
+//	    return server.Serve(cmd.Context())
+//	})
+//
+// When the execution fails after the signal, the PersistentPostRun hooks
+// that cobra skips for the error still run, followed by the Finally hooks,
+// and the error is wrapped in an ExitError with the exit code 128+signal.
+// The process exits when the execution did not finish within the grace
+// period or on a second signal.
+func HandleSignals(root *cobra.Command, signals ...os.Signal) *SignalHandler {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt}
+	}
+	h := &SignalHandler{
+		GracePeriod: DefaultGracePeriod,
+		signals:     signals,
+	}
+	h.ctx = &signalContext{ctx: context.Background()}
+	signalHandlers[root] = h
+	return h
+}
+
+// start starts handling the signals for an execution
+func (h *SignalHandler) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.ctx.set(ctx)
+	h.mu.Lock()
+	h.interrupted = nil
+	h.done = make(chan struct{})
+	done := h.done
+	// The signals of a previous execution are not delivered on a new channel
+	h.notify = make(chan os.Signal, 2)
+	notify := h.notify
+	h.mu.Unlock()
+	signal.Notify(notify, h.signals...)
+	go h.watch(notify, cancel, done)
+}
+
+// watch cancels the execution on the first signal and exits the process on
+// the second signal or when the grace period ends
+func (h *SignalHandler) watch(notify <-chan os.Signal, cancel context.CancelFunc, done chan struct{}) {
+	defer cancel()
+	var (
+		first os.Signal
+		grace <-chan time.Time
+	)
+	for {
+		select {
+		case sig := <-notify:
+			if first != nil {
+				exitFunc(signalExitCode(sig))
+				return
+			}
+			first = sig
+			h.mu.Lock()
+			h.interrupted = sig
+			h.mu.Unlock()
+			cancel()
+			timer := time.NewTimer(h.GracePeriod)
+			defer timer.Stop()
+			grace = timer.C
+		case <-grace:
+			exitFunc(signalExitCode(first))
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// signal returns the signal the execution was interrupted by
+func (h *SignalHandler) signal() os.Signal {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.interrupted
+}
+
+// stop stops handling the signals and returns the error of the execution
+func (h *SignalHandler) stop(err error) error {
+	signal.Stop(h.notify)
+	h.mu.Lock()
+	close(h.done)
+	sig := h.interrupted
+	h.mu.Unlock()
+	var exitErr *ExitError
+	if sig != nil && err != nil && !errors.As(err, &exitErr) {
+		err = &ExitError{Code: signalExitCode(sig), Err: err}
+	}
+	return err
+}
+
+// teardown runs the PersistentPostRun hooks cobra skipped for an execution
+// that failed after a signal
+func (h *SignalHandler) teardown(cmd *cobra.Command, err error) {
+	if cmd == nil || err == nil || h.signal() == nil {
+		return
+	}
+	// Only when the hooks of the command started running
+	e := current
+	if e == nil || e.cmd != cmd || e.redirected || e.phase >= PersistentPostRunPhase {
+		return
+	}
+	args := cmd.Flags().Args()
+	if e.argsRewritten {
+		args = e.args
+	}
+	// The error of the execution takes precedence
+	runPhaseHooks(cmd, args, PersistentPostRunPhase, e.mode)
+}
+
+// executeRoot executes the root command with the context of the signal handler
+func (h *SignalHandler) executeRoot(root *cobra.Command) (*cobra.Command, error) {
+	// Cobra only sets the context of the commands that have none
+	var err error
+	walkCommands(root, func(c *cobra.Command) {
+		if err == nil && c.Context() != h.ctx {
+			err = setContext(c, h.ctx)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return root.ExecuteC()
+}
+
+// signalExitCode returns the conventional exit code for the signal
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// signalContext is the context of the commands, it delegates to the context
+// of the current execution
+type signalContext struct {
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+func (c *signalContext) set(ctx context.Context) {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.mu.Unlock()
+}
+
+func (c *signalContext) current() context.Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ctx
+}
+
+func (c *signalContext) Deadline() (time.Time, bool) {
+	return c.current().Deadline()
+}
+
+func (c *signalContext) Done() <-chan struct{} {
+	return c.current().Done()
+}
+
+func (c *signalContext) Err() error {
+	return c.current().Err()
+}
+
+func (c *signalContext) Value(key interface{}) interface{} {
+	return c.current().Value(key)
+}
@@ -0,0 +1,143 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestHandleSignals(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	childCmd := &cobra.Command{Use: "child"}
+	rootCmd.AddCommand(childCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	HandleSignals(rootCmd, os.Interrupt, syscall.SIGTERM)
+
+	var (
+		ran  []string
+		wait bool
+	)
+	started := make(chan struct{})
+	OnPersistentPreRun(rootCmd, func(_ *cobra.Command, _ []string) error {
+		ran = append(ran, "setup")
+		return nil
+	})
+	OnRun(childCmd, func(cmd *cobra.Command, _ []string) error {
+		ran = append(ran, "run")
+		if !wait {
+			return cmd.Context().Err()
+		}
+		close(started)
+		<-cmd.Context().Done()
+		return cmd.Context().Err()
+	})
+	OnPersistentPostRun(rootCmd, func(_ *cobra.Command, _ []string) error {
+		ran = append(ran, "cleanup")
+		return nil
+	})
+	OnFinally(rootCmd, func(_ *cobra.Command, _ []string) error {
+		ran = append(ran, "finally")
+		return nil
+	})
+	SetArgs(rootCmd, []string{"child"})
+
+	wait = true
+	go func() {
+		<-started
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+	}()
+	err := Execute(rootCmd)
+	if !errors.Is(err, context.Canceled) || ExitCode(err) != 128+int(syscall.SIGINT) {
+		t.Errorf("Expected the canceled execution to exit with %d, got %v (%d)", 128+int(syscall.SIGINT), err, ExitCode(err))
+	}
+	if got := strings.Join(ran, " "); got != "setup run cleanup finally" {
+		t.Errorf("Expected the cleanup hooks to run after the signal, got %q", got)
+	}
+
+	ran, wait = nil, false
+	if err := Execute(rootCmd); err != nil {
+		t.Errorf("Expected a new context for the next execution, got %v", err)
+	}
+	if got := strings.Join(ran, " "); got != "setup run cleanup finally" {
+		t.Errorf("Unexpected hooks %q", got)
+	}
+}
+
+func TestHandleSignalsBetweenExecutions(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	childCmd := &cobra.Command{Use: "child"}
+	otherCmd := &cobra.Command{Use: "other"}
+	rootCmd.AddCommand(childCmd, otherCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+	h := HandleSignals(rootCmd)
+
+	// The signal arrives as the command finishes
+	OnRun(childCmd, func(_ *cobra.Command, _ []string) error {
+		h.notify <- os.Interrupt
+		return nil
+	})
+	SetArgs(rootCmd, []string{"other"})
+	// The executed command is the one cobra executed
+	rootCmd.SetArgs([]string{"child"})
+	if cmd, _ := ExecuteC(rootCmd); cmd != childCmd {
+		t.Errorf("Expected the executed command to be %q, got %q", childCmd.CommandPath(), cmd.CommandPath())
+	}
+
+	var ctxErr error
+	OnRun(otherCmd, func(cmd *cobra.Command, _ []string) error {
+		time.Sleep(10 * time.Millisecond)
+		ctxErr = cmd.Context().Err()
+		return nil
+	})
+	SetArgs(rootCmd, []string{"other"})
+	if err := Execute(rootCmd); err != nil || ctxErr != nil {
+		t.Errorf("Expected the next execution not to be canceled, got %v, %v", err, ctxErr)
+	}
+}
+
+func TestHandleSignalsForceExit(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	h := HandleSignals(rootCmd)
+
+	exited := make(chan int, 1)
+	SetExitFunc(func(code int) { exited <- code })
+	defer SetExitFunc(nil)
+
+	var (
+		signals []os.Signal
+		code    int
+	)
+	// The hook ignores the context
+	OnRun(rootCmd, func(_ *cobra.Command, _ []string) error {
+		for _, sig := range signals {
+			h.notify <- sig
+		}
+		select {
+		case got := <-exited:
+			if got != code {
+				t.Errorf("Expected to exit with %d, got %d", code, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("Expected to exit after %v", signals)
+		}
+		return nil
+	})
+	SetArgs(rootCmd, nil)
+
+	h.GracePeriod = time.Hour
+	signals, code = []os.Signal{os.Interrupt, syscall.SIGTERM}, 128+int(syscall.SIGTERM)
+	Execute(rootCmd)
+
+	h.GracePeriod = time.Millisecond
+	signals, code = []os.Signal{os.Interrupt}, 128+int(syscall.SIGINT)
+	Execute(rootCmd)
+}
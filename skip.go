@@ -0,0 +1,73 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// SkipHooksFlag is the name of the flag added by AddSkipHooksFlag.
+const SkipHooksFlag = "skip-hooks"
+
+var disabled = make(map[string]bool)
+
+// Disable disables the hooks with the name or label.
+func Disable(name string) {
+	disabled[name] = true
+}
+
+// Enable enables the hooks with the name or label again.
+func Enable(name string) {
+	delete(disabled, name)
+}
+
+// skipHooksEnv holds the environment variable of the root commands with the skip hooks flag
+var skipHooksEnv = make(map[*cobra.Command]string)
+
+// AddSkipHooksFlag adds a persistent --skip-hooks flag to the root command
+// that skips the hooks with the given names or labels for one invocation.
+// The hooks to skip can also be set with the <ROOT>_SKIP_HOOKS environment
+// variable (e.g. MYAPP_SKIP_HOOKS=telemetry,update-check for the myapp command).
+func AddSkipHooksFlag(root *cobra.Command) {
+	root.PersistentFlags().StringSlice(SkipHooksFlag, nil, "skip the hooks with the given names or labels")
+	env := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(root.Name())) + "_SKIP_HOOKS"
+	skipHooksEnv[root] = env
+}
+
+// disabledReason returns why the hook is disabled for the command, or an empty string when it is not
+func (ch *commandHook) disabledReason(cmd *cobra.Command) string {
+	if len(disabled) > 0 {
+		if ch.name != "" && disabled[ch.name] {
+			return "disabled"
+		}
+		for _, l := range ch.labels {
+			if disabled[l] {
+				return "disabled"
+			}
+		}
+	}
+	if ch.name == "" && len(ch.labels) == 0 {
+		return ""
+	}
+	if f := cmd.Flags().Lookup(SkipHooksFlag); f != nil && f.Changed {
+		names, _ := cmd.Flags().GetStringSlice(SkipHooksFlag)
+		for _, name := range names {
+			if ch.matches(name) {
+				return "skipped by --" + SkipHooksFlag
+			}
+		}
+	}
+	if env, ok := skipHooksEnv[cmd.Root()]; ok {
+		for _, name := range strings.Split(os.Getenv(env), ",") {
+			if name = strings.TrimSpace(name); name != "" && ch.matches(name) {
+				return "skipped by " + env
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package cobrahooks
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSkipHooks(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "skip-app"}}
+	childCmd := &Command{&cobra.Command{Use: "child"}}
+	rootCmd.AddCommand(childCmd.Command)
+	AddSkipHooksFlag(rootCmd.Command)
+
+	var ran []string
+	hook := func(name string) func(*cobra.Command, []string) error {
+		return func(_ *cobra.Command, _ []string) error {
+			ran = append(ran, name)
+			return nil
+		}
+	}
+	rootCmd.OnPersistentPreRun(hook("telemetry"), Name("skip-telemetry"), Labels("skip-network"))
+	rootCmd.OnPersistentPreRun(hook("update-check"), Name("skip-update-check"), Labels("skip-network"))
+	childCmd.OnRun(hook("run"))
+
+	run := func(args ...string) string {
+		ran = nil
+		if _, err := executeCommand(rootCmd.Command, args...); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+		return strings.Join(ran, " ")
+	}
+
+	if got := run("child"); got != "telemetry update-check run" {
+		t.Errorf("Expected all hooks to run, got %q", got)
+	}
+
+	Disable("skip-telemetry")
+	if got := run("child"); got != "update-check run" {
+		t.Errorf("Expected telemetry to be disabled, got %q", got)
+	}
+	Enable("skip-telemetry")
+	Disable("skip-network")
+	if got := run("child"); got != "run" {
+		t.Errorf("Expected network hooks to be disabled, got %q", got)
+	}
+	Enable("skip-network")
+
+	os.Setenv("SKIP_APP_SKIP_HOOKS", "skip-update-check")
+	got := run("child")
+	os.Unsetenv("SKIP_APP_SKIP_HOOKS")
+	if got != "telemetry run" {
+		t.Errorf("Expected update-check to be skipped, got %q", got)
+	}
+
+	if got := run("child", "--skip-hooks=skip-network"); got != "run" {
+		t.Errorf("Expected network hooks to be skipped, got %q", got)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// TraceSpan records the execution of a hook.
+type TraceSpan struct {
+	Phase   Phase
+	Command string
+	// Name is the name of the hook or else the name of its function
+	Name     string
+	Func     string
+	File     string
+	Line     int
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Tracer is a HookObserver that records the execution of the hooks.
+type Tracer struct {
+	mu    sync.Mutex
+	spans []TraceSpan
+	// root restricts the tracing to the commands of a tree, see AddTimingsFlag
+	root *cobra.Command
+}
+
+// NewTracer creates a Tracer. Register it with AddObserver to start tracing.
+func NewTracer() *Tracer {
+	return &Tracer{}
+}
+
+// BeforeHook implements HookObserver.
+func (t *Tracer) BeforeHook(e HookEvent) {}
+
+// AfterHook implements HookObserver.
+func (t *Tracer) AfterHook(e HookEvent) {
+	if t.root != nil && e.Command.Root() != t.root {
+		return
+	}
+	name := e.Hook.Name
+	if name == "" {
+		name = e.Hook.Func
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, TraceSpan{
+		Phase:    e.Hook.Phase,
+		Command:  e.Command.CommandPath(),
+		Name:     name,
+		Func:     e.Hook.Func,
+		File:     e.Hook.File,
+		Line:     e.Hook.Line,
+		Start:    e.Start,
+		Duration: e.Duration,
+		Err:      e.Err,
+	})
+}
+
+// Spans returns the recorded spans in execution order.
+func (t *Tracer) Spans() []TraceSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TraceSpan(nil), t.spans...)
+}
+
+// Reset discards the recorded spans.
+func (t *Tracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+// WriteTimings writes a report of the recorded spans and their durations.
+func (t *Tracer) WriteTimings(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PHASE\tDURATION\tCOMMAND\tHOOK")
+	var total time.Duration
+	for _, s := range t.Spans() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s (%s:%d)", s.Phase, s.Duration, s.Command, s.Name, filepath.Base(s.File), s.Line)
+		if s.Err != nil {
+			fmt.Fprintf(tw, ": %v", s.Err)
+		}
+		fmt.Fprintln(tw)
+		total += s.Duration
+	}
+	fmt.Fprintf(tw, "Total\t%s\n", total)
+	return tw.Flush()
+}
+
+type chromeTraceEvent struct {
+	Name string            `json:"name"`
+	Cat  string            `json:"cat"`
+	Ph   string            `json:"ph"`
+	Ts   int64             `json:"ts"`
+	Dur  int64             `json:"dur"`
+	Pid  int               `json:"pid"`
+	Tid  int               `json:"tid"`
+	Args map[string]string `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the recorded spans in the Chrome trace event format,
+// which can be loaded in chrome://tracing or Perfetto.
+func (t *Tracer) WriteChromeTrace(w io.Writer) error {
+	trace := struct {
+		TraceEvents []chromeTraceEvent `json:"traceEvents"`
+	}{
+		TraceEvents: []chromeTraceEvent{},
+	}
+	pid := os.Getpid()
+	for _, s := range t.Spans() {
+		args := map[string]string{
+			"command": s.Command,
+			"source":  fmt.Sprintf("%s:%d", s.File, s.Line),
+		}
+		if s.Err != nil {
+			args["error"] = s.Err.Error()
+		}
+		trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+			Name: s.Name,
+			Cat:  s.Phase.String(),
+			Ph:   "X",
+			Ts:   s.Start.UnixNano() / int64(time.Microsecond),
+			Dur:  int64(s.Duration / time.Microsecond),
+			Pid:  pid,
+			Tid:  1,
+			Args: args,
+		})
+	}
+	return json.NewEncoder(w).Encode(trace)
+}
+
+// AddTimingsFlag adds a persistent --timings flag to the root command and
+// registers the tracer for the hooks of its commands. When the flag is set,
+// the timings report is written to stderr at the end of the executions
+// through Execute, ExecuteC and ExecuteAndExit, after all hooks ran, also
+// when one of them failed. The spans are reset at the start of every
+// execution.
+func (t *Tracer) AddTimingsFlag(root *cobra.Command) {
+	var timings bool
+	root.PersistentFlags().BoolVar(&timings, "timings", false, "print the execution time of the hooks")
+	t.root = root
+	AddObserver(t)
+	executeWrappers[root] = append(executeWrappers[root], func() func(*Invocation) {
+		t.Reset()
+		return func(inv *Invocation) {
+			if inv != nil && timings {
+				// The report must not fail the execution
+				t.WriteTimings(inv.Command.ErrOrStderr())
+			}
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) BeforeHook(e HookEvent) {
+	o.events = append(o.events, "before "+e.Hook.Phase.String())
+}
+
+func (o *recordingObserver) AfterHook(e HookEvent) {
+	event := "after " + e.Hook.Phase.String()
+	if e.Err != nil {
+		event += " " + e.Err.Error()
+	}
+	o.events = append(o.events, event)
+}
+
+func TestHookObserver(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	rootCmd.OnPreRun(noopHook)
+	rootCmd.OnRun(func(_ *cobra.Command, _ []string) error {
+		return errors.New("failed")
+	})
+
+	o := &recordingObserver{}
+	AddObserver(o)
+	defer RemoveObserver(o)
+
+	if _, err := executeCommand(rootCmd.Command); err == nil {
+		t.Errorf("Expected error")
+	}
+	expected := []string{"before PreRun", "after PreRun", "before Run", "after Run failed"}
+	if len(o.events) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, o.events)
+	}
+	for i := range expected {
+		if o.events[i] != expected[i] {
+			t.Errorf("Expected events %v, got %v", expected, o.events)
+			break
+		}
+	}
+}
+
+func TestTracer(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root"}}
+	childCmd := &Command{&cobra.Command{Use: "child"}}
+	rootCmd.AddCommand(childCmd.Command)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	tracer := NewTracer()
+	tracer.AddTimingsFlag(rootCmd.Command)
+	defer RemoveObserver(tracer)
+
+	var preErr error
+	rootCmd.OnPersistentPreRun(func(_ *cobra.Command, _ []string) error { return preErr })
+	childCmd.OnRun(noopHook)
+	// Registered after the flag
+	rootCmd.OnPersistentPostRun(noopHook)
+
+	execute := func(args ...string) (string, error) {
+		buf.Reset()
+		SetArgs(rootCmd.Command, args)
+		err := Execute(rootCmd.Command)
+		return buf.String(), err
+	}
+
+	output, err := execute("child", "--timings")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "PHASE              DURATION")
+	checkStringContains(t, output, "PersistentPreRun   ")
+	checkStringContains(t, output, "root child  github.com/bartdeboer/cobrahooks.noopHook (tracer_test.go:71)")
+	checkStringContains(t, output, "PersistentPostRun  ")
+	checkStringContains(t, output, "Total  ")
+
+	spans := tracer.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+	if spans[0].Phase != PersistentPreRunPhase || spans[1].Phase != RunPhase || spans[2].Phase != PersistentPostRunPhase {
+		t.Errorf("Unexpected spans: %+v", spans)
+	}
+
+	trace := new(bytes.Buffer)
+	if err := tracer.WriteChromeTrace(trace); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var events struct {
+		TraceEvents []map[string]interface{} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(trace.Bytes(), &events); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events.TraceEvents) != 3 || events.TraceEvents[1]["ph"] != "X" || events.TraceEvents[1]["cat"] != "Run" {
+		t.Errorf("Unexpected trace: %v", trace.String())
+	}
+
+	tracer.Reset()
+	output, err = execute("child", "--timings=false")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringOmits(t, output, "PHASE")
+	if len(tracer.Spans()) != 3 {
+		t.Errorf("Expected 3 spans, got %d", len(tracer.Spans()))
+	}
+
+	// The report is most wanted when a hook failed
+	tracer.Reset()
+	preErr = errors.New("config not found")
+	output, err = execute("child", "--timings")
+	if err != preErr {
+		t.Errorf("Expected the hook error, got %v", err)
+	}
+	checkStringContains(t, output, "PersistentPreRun  ")
+	checkStringContains(t, output, "(tracer_test.go:68): config not found")
+
+	// Every report only holds its own execution, and the hooks of its tree
+	otherCmd := &cobra.Command{Use: "other", Run: emptyRun}
+	OnPreRun(otherCmd, noopHook)
+	preErr = nil
+	for i := 0; i < 3; i++ {
+		executeCommand(otherCmd)
+		output, _ = execute("child", "--timings")
+	}
+	if n := strings.Count(output, "noopHook"); n != 2 {
+		t.Errorf("Expected the report of the last execution, got %d hooks:\n%s", n, output)
+	}
+	checkStringOmits(t, output, "other")
+}
@@ -0,0 +1,191 @@
+// Copyright 2009 Bart de Boer. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cobrahooks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// UsageError is an error caused by the usage of a command, like an invalid
+// flag or invalid arguments. Cobra prints the usage of the command for it.
+// Hooks can return it to have the usage printed, the usage is not printed
+// for the other errors returned by hooks.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// IsUsageError reports whether the error is caused by the usage of the
+// command: the command is unknown, the flags failed to parse, a required flag
+// is not set, the arguments failed to validate or a hook returned a
+// UsageError. The errors returned by cobra are only classified for
+// executions through Execute, ExecuteC and ExecuteAndExit.
+func IsUsageError(err error) bool {
+	var usageErr *UsageError
+	return errors.As(err, &usageErr)
+}
+
+func usageFlagErrorFunc(f func(*cobra.Command, error) error) func(*cobra.Command, error) error {
+	return func(c *cobra.Command, err error) error {
+		if err = f(c, err); err != nil && !IsUsageError(err) {
+			err = &UsageError{Err: err}
+		}
+		return err
+	}
+}
+
+func usageArgs(args cobra.PositionalArgs) cobra.PositionalArgs {
+	return func(c *cobra.Command, a []string) error {
+		if err := args(c, a); err != nil {
+			if IsUsageError(err) {
+				return err
+			}
+			return &UsageError{Err: err}
+		}
+		return nil
+	}
+}
+
+// classified holds the flag error functions and argument validators of the
+// commands wrapped by classifyUsageErrors, to restore them after the execution
+var classified = make(map[*cobra.Command]classification)
+
+type classification struct {
+	flagErrorFunc func(*cobra.Command, error) error
+	args          cobra.PositionalArgs
+}
+
+// classifyUsageErrors makes the flag errors and argument validators of the
+// commands in the tree return UsageErrors, until restoreUsageErrors is called
+func classifyUsageErrors(root *cobra.Command) (err error) {
+	walkCommands(root, func(c *cobra.Command) {
+		if _, ok := classified[c]; ok || err != nil {
+			return
+		}
+		var orig classification
+		if orig.flagErrorFunc, err = ownFlagErrorFunc(c); err != nil {
+			return
+		}
+		orig.args = c.Args
+		classified[c] = orig
+		// The other commands inherit the function of their parent
+		if c == root || orig.flagErrorFunc != nil {
+			c.SetFlagErrorFunc(usageFlagErrorFunc(c.FlagErrorFunc()))
+		}
+		// Cobra validates the arguments differently when Args is nil
+		if c.Args != nil {
+			c.Args = usageArgs(c.Args)
+		}
+	})
+	return err
+}
+
+// restoreUsageErrors restores the flag error functions and argument
+// validators wrapped by classifyUsageErrors
+func restoreUsageErrors(root *cobra.Command) {
+	walkCommands(root, func(c *cobra.Command) {
+		if orig, ok := classified[c]; ok {
+			c.SetFlagErrorFunc(orig.flagErrorFunc)
+			c.Args = orig.args
+			delete(classified, c)
+		}
+	})
+}
+
+// initialized is set when cobra runs the initializers, once it found the
+// command, parsed its flags and checked the help and version flags
+var initialized bool
+
+func setInitialized() {
+	initialized = true
+}
+
+// classifyCommandError makes the errors cobra returns for unknown commands
+// and missing required flags UsageErrors
+func classifyCommandError(cmd *cobra.Command, err error) error {
+	if err == nil || cmd == nil || IsUsageError(err) {
+		return err
+	}
+	// The unknown commands are reported before the flags are parsed, the
+	// version is printed before the initializers run as well
+	if !initialized && current == nil && !flagSet(cmd, "version") {
+		return &UsageError{Err: err}
+	}
+	if rerr := requiredFlagsError(cmd); rerr != nil && rerr.Error() == err.Error() {
+		return &UsageError{Err: err}
+	}
+	return err
+}
+
+// requiredFlagsError returns the error cobra returns when required flags of
+// the command are not set
+func requiredFlagsError(c *cobra.Command) error {
+	var missing []string
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if required, ok := f.Annotations[cobra.BashCompOneRequiredFlag]; ok && required[0] == "true" && !f.Changed {
+			missing = append(missing, f.Name)
+		}
+	})
+	if len(missing) > 0 {
+		return fmt.Errorf(`required flag(s) "%s" not set`, strings.Join(missing, `", "`))
+	}
+	return nil
+}
+
+// silencedUsage holds the commands whose usage is silenced for a runtime
+// error, silencedErrors the commands whose errors are silenced by a hook
+var silencedUsage, silencedErrors []*cobra.Command
+
+// silenceUsage silences the usage of the command for the error unless it is a usage error
+func silenceUsage(cmd *cobra.Command, err error) {
+	if !IsUsageError(err) {
+		SilenceUsage(cmd)
+	}
+}
+
+// SilenceUsage sets the SilenceUsage field of the command for the current
+// execution, so cobra does not print the usage for the error. The field is
+// restored after the execution. It is meant for the Error hooks.
+func SilenceUsage(cmd *cobra.Command) {
+	if !cmd.SilenceUsage {
+		cmd.SilenceUsage = true
+		silencedUsage = append(silencedUsage, cmd)
+	}
+}
+
+// SilenceErrors sets the SilenceErrors field of the command for the current
+// execution, so cobra does not print the error. The field is restored after
+// the execution. It is meant for the Error hooks.
+func SilenceErrors(cmd *cobra.Command) {
+	if !cmd.SilenceErrors {
+		cmd.SilenceErrors = true
+		silencedErrors = append(silencedErrors, cmd)
+	}
+}
+
+// restoreUsage restores the SilenceUsage and SilenceErrors fields that were
+// set during the execution
+func restoreUsage() {
+	for _, c := range silencedUsage {
+		c.SilenceUsage = false
+	}
+	silencedUsage = silencedUsage[:0]
+	for _, c := range silencedErrors {
+		c.SilenceErrors = false
+	}
+	silencedErrors = silencedErrors[:0]
+}
@@ -0,0 +1,144 @@
+package cobrahooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestUsageErrors(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	childCmd := &cobra.Command{Use: "child", Args: cobra.MaximumNArgs(1)}
+	childCmd.Flags().Int("count", 0, "count")
+	rootCmd.AddCommand(childCmd)
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+
+	var runErr error
+	OnRun(childCmd, func(_ *cobra.Command, _ []string) error { return runErr })
+
+	run := func(args ...string) (string, error) {
+		buf.Reset()
+		SetArgs(rootCmd, args)
+		err := Execute(rootCmd)
+		return buf.String(), err
+	}
+
+	output, err := run("child", "--count=x")
+	if !IsUsageError(err) {
+		t.Errorf("Expected a usage error for an invalid flag, got %v", err)
+	}
+	checkStringContains(t, output, "Usage:")
+
+	output, err = run("child", "a", "b")
+	if !IsUsageError(err) {
+		t.Errorf("Expected a usage error for invalid arguments, got %v", err)
+	}
+	checkStringContains(t, output, "Usage:")
+
+	runErr = errors.New("connection refused")
+	output, err = run("child")
+	if err != runErr || IsUsageError(err) {
+		t.Errorf("Expected the runtime error, got %v", err)
+	}
+	checkStringContains(t, output, "Error: connection refused")
+	checkStringOmits(t, output, "Usage:")
+	if childCmd.SilenceUsage {
+		t.Error("Expected SilenceUsage to be restored")
+	}
+
+	runErr = &UsageError{Err: errors.New("name required")}
+	output, err = run("child")
+	if !IsUsageError(err) {
+		t.Errorf("Expected the usage error of the hook, got %v", err)
+	}
+	checkStringContains(t, output, "Usage:")
+
+	// The arguments are only wrapped once
+	runErr = nil
+	if _, err := run("child", "a", "b"); err == nil || err.Error() != "accepts at most 1 arg(s), received 2" {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if IsUsageError(errors.New("usage")) || IsUsageError(nil) {
+		t.Error("Expected other errors not to be usage errors")
+	}
+}
+
+func TestUsageErrorsRestored(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	var depths []int
+	childCmd := &cobra.Command{Use: "child", Run: emptyRun, Args: func(_ *cobra.Command, _ []string) error {
+		// Count the usageArgs wrappers the validator is called through
+		pcs := make([]uintptr, 64)
+		frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+		depth := 0
+		for {
+			f, more := frames.Next()
+			if strings.Contains(f.Function, "usageArgs.func") {
+				depth++
+			}
+			if !more {
+				break
+			}
+		}
+		depths = append(depths, depth)
+		return nil
+	}}
+	childCmd.Flags().Int("count", 0, "count")
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+
+	for i := 0; i < 2; i++ {
+		SetArgs(rootCmd, []string{"child"})
+		if err := Execute(rootCmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(depths) != 2 || depths[0] != 1 || depths[1] != 1 {
+		t.Errorf("Expected the arguments to be wrapped once per execution, got %v", depths)
+	}
+	if err := childCmd.FlagErrorFunc()(childCmd, errors.New("invalid")); IsUsageError(err) {
+		t.Error("Expected the flag error function to be restored")
+	}
+
+	// A flag error function set later applies to the childs
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return fmt.Errorf("custom: %w", err)
+	})
+	SetArgs(rootCmd, []string{"child", "--count=x"})
+	if err := Execute(rootCmd); !IsUsageError(err) || !strings.HasPrefix(err.Error(), "custom: ") {
+		t.Errorf("Expected the custom usage error, got %v", err)
+	}
+}
+
+func TestCommandUsageErrors(t *testing.T) {
+	rootCmd := &cobra.Command{Use: "root"}
+	childCmd := &cobra.Command{Use: "child", RunE: func(_ *cobra.Command, _ []string) error {
+		return errors.New("connection refused")
+	}}
+	childCmd.Flags().String("name", "", "")
+	childCmd.MarkFlagRequired("name")
+	rootCmd.AddCommand(childCmd)
+	rootCmd.SetOut(new(bytes.Buffer))
+
+	run := func(args ...string) error {
+		SetArgs(rootCmd, args)
+		return Execute(rootCmd)
+	}
+	if err := run("nope"); !IsUsageError(err) || !strings.HasPrefix(err.Error(), `unknown command "nope" for "root"`) {
+		t.Errorf("Expected a usage error for an unknown command, got %v", err)
+	}
+	if err := run("child"); !IsUsageError(err) || err.Error() != `required flag(s) "name" not set` {
+		t.Errorf("Expected a usage error for a missing required flag, got %v", err)
+	}
+	// The errors of user-defined fields are runtime errors
+	if err := run("child", "--name=x"); err == nil || IsUsageError(err) {
+		t.Errorf("Expected a runtime error, got %v", err)
+	}
+}
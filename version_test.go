@@ -0,0 +1,60 @@
+package cobrahooks
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestVersionHooks(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Version: "1.0.0", Run: emptyRun}}
+	childCmd := &Command{&cobra.Command{Use: "child", Version: "2.0.0", Run: emptyRun}}
+	rootCmd.AddCommand(childCmd.Command)
+
+	var servers string
+	rootCmd.OnPersistentPreRun(func(cmd *cobra.Command, args []string) error {
+		servers = "server 3.1"
+		return nil
+	}, RunOnVersion)
+	rootCmd.OnPersistentPreRun(func(cmd *cobra.Command, args []string) error {
+		cmd.OutOrStdout().Write([]byte("Should not run "))
+		return nil
+	})
+	rootCmd.OnPreRun(func(cmd *cobra.Command, args []string) error {
+		cmd.Version += " (build abc)"
+		return nil
+	}, RunOnVersion)
+	rootCmd.OnVersion(func(cmd *cobra.Command, args []string) error {
+		cmd.Version += " (" + servers + ")"
+		return nil
+	}, Persistent)
+
+	output, err := executeCommand(rootCmd.Command, "--version")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "root version 1.0.0 (build abc) (server 3.1)\n")
+	checkStringOmits(t, output, "Should not run")
+
+	output, err = executeCommand(rootCmd.Command, "child", "--version")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	checkStringContains(t, output, "child version 2.0.0 (server 3.1)\n")
+	checkStringOmits(t, output, "Should not run")
+}
+
+func TestVersionHookError(t *testing.T) {
+	rootCmd := &Command{&cobra.Command{Use: "root", Version: "1.0.0", Run: emptyRun}}
+	rootCmd.OnVersion(func(cmd *cobra.Command, args []string) error {
+		return errors.New("version hook failed")
+	})
+
+	output, err := executeCommand(rootCmd.Command, "--version")
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+	checkStringContains(t, output, "version hook failed")
+	checkStringOmits(t, output, "root version 1.0.0")
+}